@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+)
+
+// Codes in the code field of an APIError
+const (
+	CodeInvalidName         = "invalid_name"
+	CodeInvalidNamespace    = "invalid_namespace"
+	CodeInvalidArgument     = "invalid_argument"
+	CodeNotFound            = "not_found"
+	CodeNoZonefile          = "no_zonefile"
+	CodeNoQuorum            = "no_quorum"
+	CodeUpstreamError       = "upstream_error"
+	CodeUpstreamUnavailable = "upstream_unavailable"
+	CodeZonefileMismatch    = "zonefile_hash_mismatch"
+	CodeInternal            = "internal_error"
+)
+
+// APIError is the body of every error response, Status is the HTTP status it is sent with
+type APIError struct {
+	Status int    `json:"-"`
+	Code   string `json:"code"`
+	Err    string `json:"error"`
+}
+
+// Error satisfies the error interface
+func (err APIError) Error() string {
+	return err.Err
+}
+
+// JSON allows for easy Marshal
+func (err APIError) JSON() string {
+	byt, e := json.Marshal(err)
+	if e != nil {
+		log.Fatal(e)
+	}
+	return string(byt)
+}
+
+// PrettyJSON allows for easy Marshal
+func (err APIError) PrettyJSON() string {
+	byt, e := json.MarshalIndent(err, "", "    ")
+	if e != nil {
+		log.Fatal(e)
+	}
+	return string(byt)
+}
+
+// newAPIError returns an APIError sent with status
+func newAPIError(status int, code, msg string) APIError {
+	return APIError{Status: status, Code: code, Err: msg}
+}
+
+// toAPIError maps the errors of the blockstack package to an APIError.
+// blockstack-core answers both missing and malformed arguments with an
+// RPCError, so those are told apart by the message
+func toAPIError(err error) APIError {
+	switch e := err.(type) {
+	case APIError:
+		return e
+	case blockstack.RPCError:
+		msg := strings.ToLower(e.Err)
+		switch {
+		case strings.Contains(msg, "not found"):
+			return newAPIError(http.StatusNotFound, CodeNotFound, e.Err)
+		case strings.Contains(msg, "invalid"):
+			return newAPIError(http.StatusBadRequest, CodeInvalidArgument, e.Err)
+		}
+		return newAPIError(http.StatusBadGateway, CodeUpstreamError, e.Err)
+	case blockstack.StatusError:
+		return newAPIError(http.StatusNotFound, CodeNotFound, "Not found.")
+	case blockstack.QuorumError:
+		return newAPIError(http.StatusBadGateway, CodeNoQuorum, e.Error())
+	case blockstack.ZonefileHashError:
+		return newAPIError(http.StatusBadGateway, CodeZonefileMismatch, e.Error())
+	case blockstack.JSONUnmarshalError:
+		return newAPIError(http.StatusBadGateway, CodeUpstreamError, e.Error())
+	case blockstack.CallError:
+		return newAPIError(http.StatusServiceUnavailable, CodeUpstreamUnavailable, e.Error())
+	case blockstack.IndexingError:
+		return newAPIError(http.StatusServiceUnavailable, CodeUpstreamUnavailable, e.Error())
+	}
+	return newAPIError(http.StatusInternalServerError, CodeInternal, err.Error())
+}
+
+// writeError writes err to w with the status it maps to
+func writeError(w http.ResponseWriter, err error) {
+	e := toAPIError(err)
+	if e.Status >= http.StatusInternalServerError {
+		log.Println(logPrefix, e.Code, err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.Status)
+	w.Write([]byte(e.JSON()))
+}
+
+// recoverer turns a panic in next into a 500 so one request can not take the server down
+func recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				log.Printf("%s panic serving %s: %v\n%s", logPrefix, r.URL.Path, rec, debug.Stack())
+				writeError(w, newAPIError(http.StatusInternalServerError, CodeInternal, "internal server error"))
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// notFound answers requests for routes that do not exist
+func notFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, newAPIError(http.StatusNotFound, CodeNotFound, "no route for "+r.URL.Path))
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+)
+
+// decodeError reads the APIError written to rec
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) APIError {
+	var e APIError
+	if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil {
+		t.Fatalf("expected an error body, got %q: %v", rec.Body.String(), err)
+	}
+	e.Status = rec.Code
+	return e
+}
+
+// TestWriteError tests that each error of the blockstack package is sent with its status and code
+func TestWriteError(t *testing.T) {
+	var tests = []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		msg    string
+	}{
+		{"api error", newAPIError(http.StatusBadRequest, CodeInvalidName, "invalid name"), http.StatusBadRequest, CodeInvalidName, "invalid name"},
+		{"rpc not found", blockstack.RPCError{Err: "Not found."}, http.StatusNotFound, CodeNotFound, "Not found."},
+		{"rpc invalid", blockstack.RPCError{Err: "Invalid name or subdomain"}, http.StatusBadRequest, CodeInvalidArgument, "Invalid name or subdomain"},
+		{"rpc other", blockstack.RPCError{Err: "database is locked"}, http.StatusBadGateway, CodeUpstreamError, "database is locked"},
+		{"status", blockstack.StatusError{RPC: "get_name_blockchain_record"}, http.StatusNotFound, CodeNotFound, "Not found."},
+		{"quorum", blockstack.QuorumError{Required: 2, Agreed: 1, Nodes: 2}, http.StatusBadGateway, CodeNoQuorum, ""},
+		{"zonefile hash", blockstack.ZonefileHashError{Mismatched: []blockstack.ZonefileMismatch{{Hash: "abc", Actual: "def"}}}, http.StatusBadGateway, CodeZonefileMismatch, ""},
+		{"json unmarshal", blockstack.JSONUnmarshalError{Err: errors.New("unexpected end of JSON input")}, http.StatusBadGateway, CodeUpstreamError, "unexpected end of JSON input"},
+		{"call", blockstack.CallError{Err: errors.New("connection refused")}, http.StatusServiceUnavailable, CodeUpstreamUnavailable, "connection refused"},
+		{"indexing", blockstack.IndexingError{Lastblock: 480000}, http.StatusServiceUnavailable, CodeUpstreamUnavailable, "blockstack-core node is still indexing"},
+		{"other", errors.New("boom"), http.StatusInternalServerError, CodeInternal, "boom"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		writeError(rec, tt.err)
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s: expected a JSON body, got Content-Type %q", tt.name, ct)
+		}
+		e := decodeError(t, rec)
+		if e.Status != tt.status || e.Code != tt.code {
+			t.Errorf("%s: expected %d %s, got %d %s", tt.name, tt.status, tt.code, e.Status, e.Code)
+		}
+		if tt.msg != "" && e.Err != tt.msg {
+			t.Errorf("%s: expected error %q, got %q", tt.name, tt.msg, e.Err)
+		}
+		if e.Err == "" {
+			t.Errorf("%s: expected an error message", tt.name)
+		}
+	}
+}
+
+// TestRecoverer tests that a panicking handler is answered with a 500
+func TestRecoverer(t *testing.T) {
+	h := recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/names/muneeb.id", nil))
+	if e := decodeError(t, rec); e.Status != http.StatusInternalServerError || e.Code != CodeInternal {
+		t.Errorf("expected 500 %s, got %d %s", CodeInternal, e.Status, e.Code)
+	}
+
+	// http.ErrAbortHandler is left for net/http to handle
+	abort := recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Errorf("expected http.ErrAbortHandler to be panicked again, got %v", rec)
+		}
+	}()
+	abort.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
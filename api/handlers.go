@@ -1,47 +1,289 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/blockstack/blockstack.go/blockstack"
 	"github.com/blockstack/blockstack.go/indexer"
 	"github.com/gorilla/mux"
-	"github.com/miekg/dns"
 )
 
 const (
 	logPrefix = "[api]"
+
+	// tipUpdateInterval is how often the chain tip is checked to see if the index is behind
+	tipUpdateInterval = 30 * time.Second
 )
 
+// Config configures the blockstack-core nodes behind the API
+type Config struct {
+	// URLs of the blockstack-core nodes, only the nodes in consensus are used
+	URLs []string
+	Pool blockstack.PoolConfig
+
+	// Quorum is the number of nodes that must agree on a name record
+	// before it is returned. 0 or 1 reads from a single node
+	Quorum int
+
+	// ClientUpdateInterval is how often the nodes in consensus are checked again, 0 never
+	ClientUpdateInterval time.Duration
+
+	// Index runs an Indexer with this config alongside the API. Names, profiles,
+	// owners and namespace listings are then served from its Store. nil serves
+	// every request from blockstack-core
+	Index *indexer.Config
+
+	// MaxIndexLag is how many blocks the index can be behind the chain tip
+	// before requests fall back to blockstack-core
+	MaxIndexLag int
+
+	// ProofEndpoint, when set, is where social proofs are fetched from
+	// instead of each service's host
+	ProofEndpoint string
+}
+
 // Handlers is a collection of Hanlder
 type Handlers struct {
-	Client  *blockstack.Client
+	Pool    *blockstack.Pool
 	Indexer *indexer.Indexer
 
+	config    Config
 	lastBlock int
+	proofs    *indexer.ProofChecker
+	profiles  *indexer.ProfileFetcher
+
+	sync.Mutex
 }
 
 // NewHandlers creates the Handlers struct where all the handlers are defined.
 // It is defined this way so database connections and other clients
 // can be shared between handler methods easily
-func NewHandlers(conf blockstack.ServerConfig) *Handlers {
+func NewHandlers(conf Config) *Handlers {
+	if conf.Quorum > len(conf.URLs) {
+		log.Fatalf("%s Quorum of %d needs at least as many nodes, %d configured", logPrefix, conf.Quorum, len(conf.URLs))
+	}
 	h := &Handlers{
-		Client: blockstack.NewClient(conf),
+		Pool:     blockstack.NewPool(conf.Pool),
+		config:   conf,
+		proofs:   indexer.NewProofChecker(nil),
+		profiles: indexer.NewProfileFetcher(0, 0, 0),
 	}
-	res, err := h.Client.GetInfo()
+	h.proofs.Endpoint = conf.ProofEndpoint
+	h.setClients()
+	if h.Pool.Len() == 0 {
+		log.Fatalf("%s No blockstack-core nodes in consensus", logPrefix)
+	}
+	res, err := h.client().GetInfo()
 	if err != nil {
 		log.Fatalf("Failed to contact blockstack-core node: %v", err)
 	}
-	h.lastBlock = res.LastBlockSeen
+	h.lastBlock = res.LastBlockProcessed
+	if conf.ClientUpdateInterval > 0 {
+		go h.runClientUpdater()
+	}
+	if conf.Index != nil {
+		h.startIndexer()
+	}
 	return h
 }
 
+// startIndexer starts an Indexer with the configured Store and keeps track of
+// the chain tip to know when the index is behind
+func (h *Handlers) startIndexer() {
+	idx, err := indexer.NewIndexer(h.config.Index)
+	if err != nil {
+		log.Fatalf("%s Unable to open the index: %v", logPrefix, err)
+	}
+	h.config.Index.SetClients()
+	h.Indexer = idx
+	go idx.Start()
+	go h.runTipUpdater()
+}
+
+// Run as a goroutine to keep the chain tip up to date
+func (h *Handlers) runTipUpdater() {
+	for {
+		time.Sleep(tipUpdateInterval)
+		res, err := h.client().GetInfo()
+		if err != nil {
+			log.Println(logPrefix, "Unable to check the chain tip:", err)
+			continue
+		}
+		h.Lock()
+		h.lastBlock = res.LastBlockProcessed
+		h.Unlock()
+	}
+}
+
+// indexFresh reports whether there is an index within MaxIndexLag blocks of the chain tip
+func (h *Handlers) indexFresh() bool {
+	if h.Indexer == nil {
+		return false
+	}
+	indexed := h.Indexer.IndexedBlock()
+	h.Lock()
+	tip := h.lastBlock
+	h.Unlock()
+	return indexed > 0 && tip-indexed <= h.config.MaxIndexLag
+}
+
+// indexedDomain returns the domain for name from the index. It returns nil when
+// the index is behind or does not hold the full record for name
+func (h *Handlers) indexedDomain(name string) *indexer.Domain {
+	if !h.indexFresh() {
+		return nil
+	}
+	d, err := h.Indexer.Store().GetDomain(name)
+	if err != nil {
+		if err != indexer.ErrNotFound {
+			log.Println(logPrefix, "Index lookup for", name, "failed:", err)
+		}
+		return nil
+	}
+	// Records core did not vouch for are looked up again
+	if !d.BlockchainRecord.Status {
+		return nil
+	}
+	// Expiring is not a name operation so the record is not indexed again
+	// when it happens, the status is derived at the chain tip instead
+	h.Lock()
+	d.BlockchainRecord.Lastblock = h.lastBlock
+	h.Unlock()
+	d.Status = blockstack.NameStatus(d.BlockchainRecord)
+	return d
+}
+
+// zonefile returns the zonefile with hash from the indexed domain d
+// when it has it, otherwise from blockstack-core. Only zonefiles that
+// hash to hash are returned
+func (h *Handlers) zonefile(d *indexer.Domain, hash string) (string, error) {
+	if d != nil && d.Zonefile != nil && d.Zonefile.Raw != "" && d.BlockchainRecord.Record.ValueHash == hash && d.ZonefileVerified() {
+		return d.Zonefile.Raw, nil
+	}
+	zonefile, err := h.client().GetZonefiles([]string{hash})
+	if err != nil {
+		return "", err
+	}
+	return zonefile.Decode()[hash], nil
+}
+
+// setClients puts the configured nodes that are in consensus in the pool.
+// The pool is left alone if none of them are
+func (h *Handlers) setClients() {
+	clients, errs := blockstack.ValidClients(h.config.URLs)
+	for _, err := range errs {
+		if er, ok := err.(blockstack.ClientRegistrationError); ok {
+			log.Println(logPrefix, er.URL, er.Err)
+		}
+	}
+	if len(clients) == 0 {
+		log.Println(logPrefix, "No blockstack-core nodes in consensus, keeping the current ones")
+		return
+	}
+	if len(clients) < h.config.Quorum {
+		log.Printf("%s Only %d nodes in consensus, name lookups need %d to agree", logPrefix, len(clients), h.config.Quorum)
+	}
+	h.Pool.Set(clients)
+}
+
+// Run as a goroutine to continually update clients
+func (h *Handlers) runClientUpdater() {
+	for {
+		time.Sleep(h.config.ClientUpdateInterval)
+		log.Println(logPrefix, "Updating blockstack-core clients...")
+		h.setClients()
+	}
+}
+
+// client returns the next client from the pool
+func (h *Handlers) client() *blockstack.Client {
+	return h.Pool.Next()
+}
+
+// nameRecord fetches the record for name, from a quorum of nodes if one is configured
+func (h *Handlers) nameRecord(ctx context.Context, name string) (blockstack.GetNameBlockchainRecordResult, blockstack.Error) {
+	if h.config.Quorum > 1 {
+		return h.Pool.GetNameBlockchainRecordQuorum(ctx, name, h.config.Quorum)
+	}
+	return h.client().GetNameBlockchainRecordContext(ctx, name)
+}
+
+// domain returns the domain for name from the index or, for a subdomain, from
+// the zonefile of its parent. It returns nil for on-chain names that are not
+// in the index. Errors are written to w and false is returned
+func (h *Handlers) domain(w http.ResponseWriter, r *http.Request, name string) (*indexer.Domain, bool) {
+	if d := h.indexedDomain(name); d != nil {
+		return d, true
+	}
+	spl := strings.Split(name, ".")
+	if len(spl) != 3 {
+		return nil, true
+	}
+
+	parentName := strings.Join(spl[1:], ".")
+	parent := h.indexedDomain(parentName)
+	parentDetails, ok := h.nameDetails(w, r, parent, parentName)
+	if !ok {
+		return nil, false
+	}
+	if parentDetails.Status && parentDetails.Record.ValueHash != "" {
+		if parent == nil {
+			zonefile, err := h.zonefile(nil, parentDetails.Record.ValueHash)
+			if err != nil {
+				writeError(w, err)
+				return nil, false
+			}
+			parent = indexer.NewDomain(parentName)
+			parent.BlockchainRecord = parentDetails
+			parent.AddZonefile(zonefile)
+		}
+		for _, sub := range parent.Subdomains() {
+			if sub.Name == name {
+				return sub, true
+			}
+		}
+	}
+	writeError(w, newAPIError(http.StatusNotFound, CodeNotFound, "Not found."))
+	return nil, false
+}
+
+// nameDetails returns the record of the indexed domain d or, when d is nil,
+// fetches it for name. Errors are written to w and false is returned
+func (h *Handlers) nameDetails(w http.ResponseWriter, r *http.Request, d *indexer.Domain, name string) (blockstack.GetNameBlockchainRecordResult, bool) {
+	if d != nil {
+		return d.BlockchainRecord, true
+	}
+	nameDetails, err := h.nameRecord(r.Context(), name)
+	// status false is returned with the record and handled by the caller
+	_, notRegistered := err.(blockstack.StatusError)
+	if err != nil && !notRegistered {
+		writeError(w, err)
+		return nameDetails, false
+	}
+	return nameDetails, true
+}
+
+// checkName writes an error to w and returns false if name is not a name
+// or subdomain in the id namespace
+func checkName(w http.ResponseWriter, name string) bool {
+	spl := strings.Split(name, ".")
+	if len(spl) != 3 && len(spl) != 2 {
+		writeError(w, newAPIError(http.StatusBadRequest, CodeInvalidName, "invalid name"))
+		return false
+	} else if spl[len(spl)-1] != "id" {
+		writeError(w, newAPIError(http.StatusBadRequest, CodeInvalidNamespace, "invalid namespace"))
+		return false
+	}
+	return true
+}
+
 func jsonKV(k, v string) []byte {
 	ret, _ := json.Marshal(map[string]string{k: v})
 	return ret
@@ -50,52 +292,31 @@ func jsonKV(k, v string) []byte {
 // V1GetNameHandler handles the /v1/names/{name} route
 func (h *Handlers) V1GetNameHandler(w http.ResponseWriter, r *http.Request) {
 	name := mux.Vars(r)["name"]
-	spl := strings.Split(name, ".")
-	if len(spl) != 3 && len(spl) != 2 {
-		w.Write(jsonKV("error", "invalid name"))
+	if !checkName(w, name) {
 		return
-	} else if spl[len(spl)-1] != "id" {
-		w.Write(jsonKV("error", "invalid namespace"))
+	}
+	d, ok := h.domain(w, r, name)
+	if !ok {
 		return
 	}
-	nameDetails, err := h.Client.GetNameBlockchainRecord(name)
-	if err != nil {
-		strs := strings.Split(err.Error(), ": ")
-		if len(strs) == 0 {
-			w.Write(jsonKV("error", err.Error()))
-			return
-		} else if err.Error() == "Not found." {
-			w.Write(jsonKV("error", err.Error()))
-			return
-		} else {
-			w.Write(jsonKV("error", strs[1]))
-			return
-		}
+	nameDetails, ok := h.nameDetails(w, r, d, name)
+	if !ok {
+		return
 	}
 
-	// if there are no name details then the name is available
-	if !nameDetails.Status {
-		w.Write(jsonKV("status", "available"))
+	status := blockstack.NameStatus(nameDetails)
+	if status == blockstack.StatusAvailable {
+		w.Write(jsonKV("status", status))
 		return
 	}
-	// divine the status of the name
-	lastTx := nameDetails.LastTx()
-	var status string
-	if lastTx.Opcode == "NAME_PREORDER" {
-		status = "pending"
-	} else if nameDetails.Record.ExpireBlock > nameDetails.Lastblock {
-		status = "expired"
-	} else {
-		status = "registered"
-	}
 
 	// If it is registered and there is a zonefile hash look that up
 	if nameDetails.Status && nameDetails.Record.ValueHash != "" {
-		zonefile, err := h.Client.GetZonefiles([]string{nameDetails.Record.ValueHash})
+		zonefile, err := h.zonefile(d, nameDetails.Record.ValueHash)
 		if err != nil {
-			log.Fatal(err)
+			writeError(w, err)
+			return
 		}
-
 		out := V1GetNameResponse{
 			Address:      nameDetails.Record.Address,
 			Blockchain:   "bitcoin",
@@ -103,7 +324,8 @@ func (h *Handlers) V1GetNameHandler(w http.ResponseWriter, r *http.Request) {
 			LastTxid:     nameDetails.Record.Txid,
 			Status:       status,
 			ZonefileHash: nameDetails.Record.ValueHash,
-			Zonefile:     zonefile.Decode()[nameDetails.Record.ValueHash],
+			Zonefile:     zonefile,
+			Seqn:         seqn(d),
 		}
 		w.Write(out.JSON())
 		return
@@ -116,35 +338,30 @@ func (h *Handlers) V1GetNameHandler(w http.ResponseWriter, r *http.Request) {
 			Status:       status,
 			ZonefileHash: nameDetails.Record.ValueHash,
 			Zonefile:     map[string]string{"error": "No zone file loaded"},
+			Seqn:         seqn(d),
 		}
 		w.Write(out.JSON())
 		return
 	}
-	w.Write(jsonKV("error", "slipped request"))
+	writeError(w, newAPIError(http.StatusInternalServerError, CodeInternal, "slipped request"))
 }
 
 // V1GetNameHistoryHandler handles response for /v1/names/{name}/history
 func (h *Handlers) V1GetNameHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	name := mux.Vars(r)["name"]
-	spl := strings.Split(name, ".")
-	if len(spl) != 3 && len(spl) != 2 {
-		w.Write(jsonKV("error", "invalid name"))
-		return
-	} else if spl[len(spl)-1] != "id" {
-		w.Write(jsonKV("error", "invalid namespace"))
+	if !checkName(w, name) {
 		return
 	}
-	res, err := h.Client.GetNameBlockchainRecord(name)
+	res, err := h.client().GetNameBlockchainRecord(name)
 	if err != nil {
-		er := strings.Split(err.Error(), ": ")[1]
-		if er == "invalid name" {
-			w.Write(jsonKV("error", er))
-			return
-		}
+		writeError(w, err)
+		return
 	}
 	out := V1GetNameHistoryResponse{}
 	for k := range res.Record.History {
-		// TODO: Maybe check length here. We will see
+		if len(res.Record.History[k]) == 0 {
+			continue
+		}
 		tx := res.Record.History[k][0]
 		out[k] = []Transaction{Transaction{
 			Address:              tx.Address,
@@ -179,12 +396,21 @@ func (h *Handlers) V1GetNamesInNamespaceHandler(w http.ResponseWriter, r *http.R
 	page := r.FormValue("page")
 	pg, err := strconv.ParseInt(page, 10, 64)
 	if err != nil {
-		w.Write(jsonKV("error", "invalid integer for page"))
+		writeError(w, newAPIError(http.StatusBadRequest, CodeInvalidArgument, "invalid integer for page"))
 		return
 	}
-	res, err := h.Client.GetNamesInNamespace(vars["namespace"], (int(pg) * 100), 100)
+	if h.indexFresh() {
+		domains, err := h.Indexer.Store().ListByNamespace(vars["namespace"], int(pg)*100, 100)
+		if err != nil {
+			log.Println(logPrefix, "Index lookup for namespace", vars["namespace"], "failed:", err)
+		} else if len(domains) > 0 {
+			w.Write(V1GetNamesInNamespaceResponse(domainNames(domains)).JSON())
+			return
+		}
+	}
+	res, err := h.client().GetNamesInNamespace(vars["namespace"], (int(pg) * 100), 100)
 	if err != nil {
-		w.Write([]byte("[]"))
+		writeError(w, err)
 		return
 	}
 	out := V1GetNamesInNamespaceResponse(res.Names)
@@ -194,62 +420,64 @@ func (h *Handlers) V1GetNamesInNamespaceHandler(w http.ResponseWriter, r *http.R
 // V2GetUserProfileHandler handles response for /v2/users/{name} route
 func (h *Handlers) V2GetUserProfileHandler(w http.ResponseWriter, r *http.Request) {
 	name := mux.Vars(r)["name"]
-	spl := strings.Split(name, ".")
-	if len(spl) != 3 && len(spl) != 2 {
-		w.Write(jsonKV("error", "invalid name"))
+	if !checkName(w, name) {
 		return
-	} else if spl[len(spl)-1] != "id" {
-		w.Write(jsonKV("error", "invalid namespace"))
+	}
+	d, ok := h.domain(w, r, name)
+	if !ok {
 		return
 	}
-	nameDetails, err := h.Client.GetNameBlockchainRecord(name)
-	if err != nil {
-		strs := strings.Split(err.Error(), ": ")
-		if len(strs) == 0 {
-			w.Write(jsonKV("error", err.Error()))
-			return
-		} else if err.Error() == "Not found." {
-			w.Write(jsonKV("error", err.Error()))
-			return
-		} else {
-			w.Write(jsonKV("error", strs[1]))
-			return
-		}
+	nameDetails, ok := h.nameDetails(w, r, d, name)
+	if !ok {
+		return
 	}
 
-	// if there are no name details then the name is available
-	if !nameDetails.Status {
-		w.Write(jsonKV("status", "available"))
+	status := blockstack.NameStatus(nameDetails)
+	if status == blockstack.StatusAvailable {
+		w.Write(jsonKV("status", status))
 		return
 	}
-
-	// divine the status of the name
-	lastTx := nameDetails.LastTx()
-	var status string
-	if lastTx.Opcode == "NAME_PREORDER" {
-		status = "pending"
-	} else if nameDetails.Record.ExpireBlock > nameDetails.Lastblock {
-		status = "expired"
-	} else {
-		status = "registered"
+	if nameDetails.Record.ValueHash == "" {
+		writeError(w, newAPIError(http.StatusNotFound, CodeNoZonefile, "No zone file loaded"))
+		return
 	}
-
-	// If it is registered and there is a zonefile hash look that up
-	if nameDetails.Status && nameDetails.Record.ValueHash != "" {
-		zonefile, err := h.Client.GetZonefiles([]string{nameDetails.Record.ValueHash})
+	if d == nil {
+		d = indexer.NewDomain(name)
+		d.BlockchainRecord = nameDetails
+	}
+	if !d.ZonefileVerified() {
+		zonefile, err := h.zonefile(nil, nameDetails.Record.ValueHash)
 		if err != nil {
-			log.Fatal(err)
+			writeError(w, err)
+			return
 		}
-		zf := parseZonefile(zonefile.Decode()[nameDetails.Record.ValueHash])
-		fmt.Printf("%#v\n", zf)
-		w.Write(zf.JSON())
-		fmt.Println("herehre", status)
-		return
-	} else if nameDetails.Status {
-		w.Write(jsonKV("error", "No zone file loaded"))
-		return
+		d.AddZonefile(zonefile)
 	}
-	w.Write(jsonKV("error", "slipped request"))
+
+	// Profiles missing from the index are fetched, proofs are only checked
+	// by the indexer when it is configured to
+	if d.Profile == nil {
+		d.ResolveProfile(h.profiles)
+		d.VerifyProfile()
+	}
+	if d.Proofs == nil {
+		d.CheckProofs(h.proofs)
+	}
+
+	out := V2GetUserProfile{
+		Expired:       status == blockstack.StatusExpired || status == blockstack.StatusInGracePeriod,
+		Verifications: d.Proofs,
+		ZoneFile:      d.Zonefile.JSON(),
+		Verification:  d.Verification,
+		ProfileError:  d.ProfileError,
+	}
+	if out.Verifications == nil {
+		out.Verifications = []indexer.ProofVerification{}
+	}
+	if d.Profile != nil {
+		out.Profile = d.Profile.Person()
+	}
+	w.Write(V2GetUserProfileResponse{name: out}.JSON())
 }
 
 // V1GetNameOpsAtHeightHandler handles response for /v1/blockchains/{blockchain}/operations/{blockHeight} route
@@ -259,18 +487,18 @@ func (h *Handlers) V1GetNameOpsAtHeightHandler(w http.ResponseWriter, r *http.Re
 	blockchain := vars["blockchain"]
 	bh, err := strconv.ParseInt(blockHeight, 10, 64)
 	if err != nil {
-		w.Write(jsonKV("error", "invalid integer for blockHeight"))
+		writeError(w, newAPIError(http.StatusBadRequest, CodeInvalidArgument, "invalid integer for blockHeight"))
 		return
 	} else if blockchain != "bitcoin" {
-		w.Write(jsonKV("error", "blockstack runs on the bitcoin blockchain"))
+		writeError(w, newAPIError(http.StatusBadRequest, CodeInvalidArgument, "blockstack runs on the bitcoin blockchain"))
 		return
 	} else if bh < blockstack.StartBlock {
-		w.Write(jsonKV("error", "invalid block height"))
+		writeError(w, newAPIError(http.StatusBadRequest, CodeInvalidArgument, "invalid block height"))
 		return
 	}
-	res, err := h.Client.GetNameOpsAffectedAt(int(bh), 0, 10)
+	res, err := h.client().GetNameOpsAffectedAt(int(bh), 0, 10)
 	if err != nil {
-		w.Write(jsonKV("error", err.Error()))
+		writeError(w, err)
 		return
 	} else if len(res.Nameops) == 0 {
 		w.Write([]byte("[]"))
@@ -283,14 +511,25 @@ func (h *Handlers) V1GetNameOpsAtHeightHandler(w http.ResponseWriter, r *http.Re
 // V1GetNamesOwnedByAddressHandler handles response for /v1/addresses/bitcoin/{address} route
 func (h *Handlers) V1GetNamesOwnedByAddressHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	res, err := h.Client.GetNamesOwnedByAddress(vars["address"])
-	if err != nil {
-		w.Write([]byte(err.JSON()))
-		return
+	var names []string
+	if h.indexFresh() {
+		domains, err := h.Indexer.Store().ListByOwner(vars["address"])
+		if err != nil {
+			log.Println(logPrefix, "Index lookup for address", vars["address"], "failed:", err)
+		}
+		names = domainNames(domains)
+	}
+	if len(names) == 0 {
+		res, err := h.client().GetNamesOwnedByAddress(vars["address"])
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		names = res.Names
 	}
-	out, er := json.Marshal(map[string][]string{"names": res.Names})
+	out, er := json.Marshal(map[string][]string{"names": names})
 	if er != nil {
-		w.Write(jsonKV("error", "failed to unmarshall json response"))
+		writeError(w, newAPIError(http.StatusInternalServerError, CodeInternal, "failed to marshal json response"))
 		return
 	}
 	w.Write(out)
@@ -299,58 +538,36 @@ func (h *Handlers) V1GetNamesOwnedByAddressHandler(w http.ResponseWriter, r *htt
 // V1GetZonefileHandler handles response for /v1/names/{name}/zonefile route
 func (h *Handlers) V1GetZonefileHandler(w http.ResponseWriter, r *http.Request) {
 	name := mux.Vars(r)["name"]
-	spl := strings.Split(name, ".")
-	if len(spl) != 3 && len(spl) != 2 {
-		w.Write(jsonKV("error", "invalid name"))
+	if !checkName(w, name) {
 		return
-	} else if spl[len(spl)-1] != "id" {
-		w.Write(jsonKV("error", "invalid namespace"))
+	}
+	d, ok := h.domain(w, r, name)
+	if !ok {
 		return
 	}
-	nameDetails, err := h.Client.GetNameBlockchainRecord(name)
-	if err != nil {
-		strs := strings.Split(err.Error(), ": ")
-		if len(strs) == 0 {
-			w.Write(jsonKV("error", err.Error()))
-			return
-		} else if err.Error() == "Not found." {
-			w.Write(jsonKV("error", err.Error()))
-			return
-		} else {
-			w.Write(jsonKV("error", strs[1]))
-			return
-		}
+	nameDetails, ok := h.nameDetails(w, r, d, name)
+	if !ok {
+		return
 	}
 
-	// if there are no name details then the name is available
-	if !nameDetails.Status {
-		w.Write(jsonKV("error", "name not registered"))
+	if blockstack.NameStatus(nameDetails) == blockstack.StatusAvailable {
+		writeError(w, newAPIError(http.StatusNotFound, CodeNotFound, "name not registered"))
 		return
 	}
-	// // divine the status of the name
-	// lastTx := nameDetails.LastTx()
-	// var status string
-	// if lastTx.Opcode == "NAME_PREORDER" {
-	// 	status = "pending"
-	// } else if nameDetails.Record.ExpireBlock > nameDetails.Lastblock {
-	// 	status = "expired"
-	// } else {
-	// 	status = "registered"
-	// }
-
 	// If it is registered and there is a zonefile hash look that up
 	if nameDetails.Record.ValueHash != "" {
-		zonefile, err := h.Client.GetZonefiles([]string{nameDetails.Record.ValueHash})
+		zonefile, err := h.zonefile(d, nameDetails.Record.ValueHash)
 		if err != nil {
-			log.Fatal(err)
+			writeError(w, err)
+			return
 		}
-		w.Write(jsonKV("zonefile", zonefile.Decode()[nameDetails.Record.ValueHash]))
+		w.Write(jsonKV("zonefile", zonefile))
 		return
 	} else if nameDetails.Status {
-		w.Write(jsonKV("error", "No zone file loaded"))
+		writeError(w, newAPIError(http.StatusNotFound, CodeNoZonefile, "No zone file loaded"))
 		return
 	}
-	w.Write(jsonKV("error", "slipped request"))
+	writeError(w, newAPIError(http.StatusInternalServerError, CodeInternal, "slipped request"))
 }
 
 // V1GetNamespaceBlockchainRecordHandler handles response for /v1/namespaces/{namespace} route
@@ -359,10 +576,10 @@ func (h *Handlers) V1GetZonefileHandler(w http.ResponseWriter, r *http.Request)
 // and it looks like core.blockstack.org has data from some other transaction
 func (h *Handlers) V1GetNamespaceBlockchainRecordHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	res, err := h.Client.GetNamespaceBlockchainRecord(vars["namespace"])
+	res, err := h.client().GetNamespaceBlockchainRecord(vars["namespace"])
 	if err != nil {
-		// TODO: return error to client here and mention that theres a connection error to core node
-		log.Fatal(err)
+		writeError(w, err)
+		return
 	}
 
 	out := V1GetNamespaceBlockchainRecordResponse{
@@ -463,87 +680,32 @@ func (h *Handlers) V1GetNamespaceBlockchainRecordHandler(w http.ResponseWriter,
 
 // V1GetNamespacesHandler handles response for /v1/namespaces route
 func (h *Handlers) V1GetNamespacesHandler(w http.ResponseWriter, r *http.Request) {
-	res, err := h.Client.GetAllNamespaces()
+	res, err := h.client().GetAllNamespaces()
 	if err != nil {
-		w.Write([]byte(err.JSON()))
+		writeError(w, err)
 		return
 	}
 	out, er := json.Marshal(res.Namespaces)
 	if er != nil {
-		w.Write(jsonKV("error", "failed to unmarshall json response"))
+		writeError(w, newAPIError(http.StatusInternalServerError, CodeInternal, "failed to marshal json response"))
 		return
 	}
 	w.Write(out)
 }
-func parseZonefile(zonefile string) *indexer.Zonefile {
-	zf := &indexer.Zonefile{
-		Raw:       zonefile,
-		RRs:       make([]dns.RR, 0),
-		Compliant: true,
-	}
-	for x := range dns.ParseZone(strings.NewReader(zonefile), "", "") {
-		fmt.Println(x)
-		if x.Error != nil {
-			zf.Compliant = false
-			log.Println(zf)
-			// 	var legacyProfile *indexer.LegacyProfile
-			// 	// NOTE: Squash error here. We don't care about it
-			// 	json.Unmarshal([]byte(zonefile), &legacyProfile)
-			// 	if legacyProfile.Account == nil {
-			// 		legacyProfile = nil
-			// 	} else {
-			// 		legacyProfile = legacyProfile
-			// 	}
-		} else {
-			// TODO: Handle fetching subdomains here...
-			// if x.RR.Header().Rrtype == 16 {
-			// 	fmt.Println(x.RR)
-			// }
-			// created_equal.self_evident_truth.iz3600	IN	TXT	"owner=1AYddAnfHbw6bPNvnsQFFrEuUdhMhf2XG9" "seqn=0" "parts=1" "zf0=JE9SSUdJTiBjcmVhdGVkX2VxdWFsCiRUVEwgMzYwMApfaHR0cHMuX3RjcCBVUkkgMTAgMSAiaHR0cHM6Ly93d3cuY3MucHJpbmNldG9uLmVkdS9+YWJsYW5rc3QvY3JlYXRlZF9lcXVhbC5qc29uIgpfZmlsZSBVUkkgMTAgMSAiZmlsZTovLy90bXAvY3JlYXRlZF9lcXVhbC5qc29uIgo="
-			zf.RRs = append(zf.RRs, x.RR)
-		}
-	}
-	return zf
-}
 
-// ResolveProfile takes an initialized domain and fetches the resulting profile for that domain
-func ResolveProfile(zf *indexer.Zonefile, name string) *indexer.Profile {
-	// fmt.Println(d.Name)
-	URI := zf.GetURI()
-	// Check that a URI was returned and there is a Target
-	if URI != nil {
-		target := URI.Target
+// seqn returns the sequence number of d if it is a subdomain
+func seqn(d *indexer.Domain) *int {
+	if d == nil || d.Subdomain == nil {
+		return nil
+	}
+	return &d.Subdomain.Seqn
+}
 
-		// Handle dropbox urls with no http prefix
-		if strings.TrimPrefix(target, "www") != target {
-			target = fmt.Sprintf("http://%s", target)
-		}
-		res, err := http.Get(target)
-		if err != nil || res.StatusCode == 404 {
-			log.Printf("Error fetching profile for %v: %v\n", name, err)
-			return nil
-		}
-		body, err := ioutil.ReadAll(res.Body)
-		if err != nil {
-			log.Printf("Error reading body for %v: %v", name, err)
-			return nil
-		}
-		var p1 []indexer.Profile
-		err = json.Unmarshal(body, &p1)
-		if err != nil && strings.Contains(err.Error(), "cannot unmarshal object") {
-			var p2 indexer.Profile
-			json.Unmarshal(body, &p2)
-			if err != nil {
-				fmt.Printf("Error unmarshalling %v\nERROR: %v\nRES: %v\nTARGET: %v\n\n", name, err, string(body), target)
-				return nil
-			}
-			return &p2
-		} else {
-			if len(p1) > 0 {
-				return &p1[0]
-			}
-		}
-		res.Body.Close()
+// domainNames returns the names of domains
+func domainNames(domains indexer.Domains) []string {
+	out := make([]string, 0, len(domains))
+	for _, d := range domains {
+		out = append(out, d.Name)
 	}
-	return nil
+	return out
 }
@@ -0,0 +1,301 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+	"github.com/blockstack/blockstack.go/blockstack/blockstacktest"
+	"github.com/blockstack/blockstack.go/indexer"
+)
+
+const muneebProfileURL = "https://gaia.blockstack.org/hub/17hEAjUUWp5wN9SEGYqxpdtjHKzWVkmHEo/3/profile.json"
+
+// testHandlers returns Handlers in front of cores, profiles and proofs are fetched from the first
+func testHandlers(conf Config, cores ...*blockstacktest.Core) *Handlers {
+	for _, c := range cores {
+		conf.URLs = append(conf.URLs, c.URL())
+	}
+	h := NewHandlers(conf)
+	h.profiles.Endpoint = cores[0].URL()
+	h.proofs.Endpoint = cores[0].URL()
+	return h
+}
+
+// get requests path from h
+func get(h *Handlers, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	newRouter(h).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+// expectStatus reports an error unless rec has status and, for errors, code
+func expectStatus(t *testing.T, path string, rec *httptest.ResponseRecorder, status int, code string) {
+	if rec.Code != status {
+		t.Errorf("%s: expected %d, got %d %s", path, status, rec.Code, rec.Body.String())
+		return
+	}
+	if code == "" {
+		return
+	}
+	if e := decodeError(t, rec); e.Code != code {
+		t.Errorf("%s: expected code %s, got %s", path, code, e.Code)
+	}
+}
+
+// nameAddress returns the address in a /v1/names/{name} response
+func nameAddress(t *testing.T, rec *httptest.ResponseRecorder) string {
+	var out struct {
+		Address string `json:"address"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("unexpected name response %q: %v", rec.Body.String(), err)
+	}
+	return out.Address
+}
+
+// TestRoutes tests every route family against blockstack-core
+func TestRoutes(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	h := testHandlers(Config{}, core)
+
+	var tests = []struct {
+		path   string
+		status int
+		code   string
+		body   string
+	}{
+		{"/v1/names/muneeb.id", http.StatusOK, "", `"address":"17hEAjUUWp5wN9SEGYqxpdtjHKzWVkmHEo"`},
+		{"/v1/names/nozonefile.id", http.StatusOK, "", `"No zone file loaded"`},
+		{"/v1/names/doesnotexist.id", http.StatusNotFound, CodeNotFound, ""},
+		{"/v1/names/muneeb", http.StatusBadRequest, CodeInvalidName, ""},
+		{"/v1/names/jude.helloworld", http.StatusBadRequest, CodeInvalidNamespace, ""},
+		{"/v1/names/muneeb.id/history", http.StatusOK, "", `"NAME_REGISTRATION"`},
+		{"/v1/names/doesnotexist.id/history", http.StatusNotFound, CodeNotFound, ""},
+		{"/v1/names/muneeb.id/zonefile", http.StatusOK, "", `/3/profile.json`},
+		{"/v1/names/nozonefile.id/zonefile", http.StatusNotFound, CodeNoZonefile, ""},
+		{"/v1/names/doesnotexist.id/zonefile", http.StatusNotFound, CodeNotFound, ""},
+		{"/v1/namespaces/id/names?page=0", http.StatusOK, "", `"muneeb.id"`},
+		{"/v1/namespaces/id/names?page=one", http.StatusBadRequest, CodeInvalidArgument, ""},
+		{"/v1/blockchains/bitcoin/operations/480003", http.StatusOK, "", `"NAME_UPDATE"`},
+		{"/v1/blockchains/bitcoin/operations/480009", http.StatusOK, "", `[]`},
+		{"/v1/blockchains/ethereum/operations/480003", http.StatusBadRequest, CodeInvalidArgument, ""},
+		{"/v1/blockchains/bitcoin/operations/1", http.StatusBadRequest, CodeInvalidArgument, ""},
+		{"/v1/addresses/bitcoin/17hEAjUUWp5wN9SEGYqxpdtjHKzWVkmHEo", http.StatusOK, "", `{"names":["muneeb.id"]}`},
+		{"/v1/namespaces/id", http.StatusOK, "", `"namespace_id":"id"`},
+		{"/v1/namespaces/doesnotexist", http.StatusNotFound, CodeNotFound, ""},
+		{"/v1/namespaces", http.StatusOK, "", `["helloworld","id"]`},
+		{"/v2/users/doesnotexist.id", http.StatusNotFound, CodeNotFound, ""},
+		{"/v2/users/nozonefile.id", http.StatusNotFound, CodeNoZonefile, ""},
+		{"/v3/names/muneeb.id", http.StatusNotFound, CodeNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := get(h, tt.path)
+		expectStatus(t, tt.path, rec, tt.status, tt.code)
+		if !strings.Contains(rec.Body.String(), tt.body) {
+			t.Errorf("%s: expected %s in %s", tt.path, tt.body, rec.Body.String())
+		}
+	}
+}
+
+// TestUpstreamErrors tests that errors from blockstack-core are sent as 502 and 503
+func TestUpstreamErrors(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	h := testHandlers(Config{}, core)
+
+	core.SetError("get_name_blockchain_record", "database is locked")
+	for _, path := range []string{"/v1/names/muneeb.id", "/v1/names/muneeb.id/history", "/v1/names/muneeb.id/zonefile", "/v2/users/muneeb.id"} {
+		expectStatus(t, path, get(h, path), http.StatusBadGateway, CodeUpstreamError)
+	}
+	core.SetError("get_name_blockchain_record", "")
+
+	// A zonefile that does not hash to the value hash is not passed on
+	hash := core.Records["muneeb.id"].Record.ValueHash
+	core.Lock()
+	core.Zonefiles[hash] = "$ORIGIN muneeb.id\n"
+	core.Unlock()
+	for _, path := range []string{"/v1/names/muneeb.id", "/v1/names/muneeb.id/zonefile", "/v2/users/muneeb.id"} {
+		expectStatus(t, path, get(h, path), http.StatusBadGateway, CodeZonefileMismatch)
+	}
+
+	core.SetUnavailable(true)
+	for _, path := range []string{"/v1/names/muneeb.id", "/v1/namespaces", "/v1/addresses/bitcoin/17hEAjUUWp5wN9SEGYqxpdtjHKzWVkmHEo"} {
+		expectStatus(t, path, get(h, path), http.StatusServiceUnavailable, CodeUpstreamUnavailable)
+	}
+}
+
+// TestQuorumRoutes tests that names are only served when a quorum of nodes agree on them
+func TestQuorumRoutes(t *testing.T) {
+	cores := []*blockstacktest.Core{blockstacktest.NewCore(), blockstacktest.NewCore()}
+	for _, c := range cores {
+		defer c.Close()
+	}
+	h := testHandlers(Config{Quorum: 2}, cores...)
+
+	rec := get(h, "/v1/names/muneeb.id")
+	expectStatus(t, "/v1/names/muneeb.id", rec, http.StatusOK, "")
+	if cores[0].Calls("get_name_blockchain_record") != 1 || cores[1].Calls("get_name_blockchain_record") != 1 {
+		t.Errorf("expected the record to be read from both nodes")
+	}
+	expectStatus(t, "/v1/names/doesnotexist.id", get(h, "/v1/names/doesnotexist.id"), http.StatusNotFound, CodeNotFound)
+
+	cores[1].TransferName("muneeb.id", "1Bv2vJMqBLrm7pRGTsMqDeoKb8bf6fsBPe", blockstacktest.FixtureLastBlock)
+	for _, path := range []string{"/v1/names/muneeb.id", "/v1/names/muneeb.id/zonefile", "/v2/users/muneeb.id"} {
+		expectStatus(t, path, get(h, path), http.StatusBadGateway, CodeNoQuorum)
+	}
+}
+
+// TestV2UserProfile tests the shape of the /v2/users/{name} response
+func TestV2UserProfile(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	claim := `{"@type":"Person","name":"Muneeb Ali"}`
+	token := "eyJ0eXAiOiJKV1QiLCJhbGciOiJFUzI1NksifQ." + base64.RawURLEncoding.EncodeToString([]byte(`{"claim":`+claim+`}`)) + ".c2lnbmF0dXJl"
+	core.AddProfile(muneebProfileURL, `[{"token": "`+token+`"}]`)
+	h := testHandlers(Config{}, core)
+
+	rec := get(h, "/v2/users/muneeb.id")
+	expectStatus(t, "/v2/users/muneeb.id", rec, http.StatusOK, "")
+	var out map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("unexpected response %q: %v", rec.Body.String(), err)
+	}
+	user, ok := out["muneeb.id"]
+	if !ok || len(out) != 1 {
+		t.Fatalf("expected the response to be keyed by muneeb.id, got %s", rec.Body.String())
+	}
+	for _, key := range []string{"expired", "profile", "verifications", "zone_file", "verification"} {
+		if _, ok := user[key]; !ok {
+			t.Errorf("expected %s in %s", key, rec.Body.String())
+		}
+	}
+	if string(user["profile"]) != claim {
+		t.Errorf("expected the claim of the profile token, got %s", user["profile"])
+	}
+	if string(user["expired"]) != "false" || string(user["verifications"]) != "[]" {
+		t.Errorf("unexpected expired %s or verifications %s", user["expired"], user["verifications"])
+	}
+	if !strings.Contains(string(user["zone_file"]), "/3/profile.json") {
+		t.Errorf("expected the parsed zonefile, got %s", user["zone_file"])
+	}
+	if _, ok := user["profile_error"]; ok {
+		t.Errorf("expected no profile_error, got %s", user["profile_error"])
+	}
+
+	// A profile that can not be fetched is null with the reason
+	rec = get(h, "/v2/users/ryan.id")
+	expectStatus(t, "/v2/users/ryan.id", rec, http.StatusOK, "")
+	var missing V2GetUserProfileResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &missing); err != nil {
+		t.Fatal(err)
+	}
+	if p := missing["ryan.id"]; string(p.Profile) != "null" || p.ProfileError == nil || p.ProfileError.Status != http.StatusNotFound {
+		t.Errorf("expected a null profile and a 404 profile_error, got %s", rec.Body.String())
+	}
+}
+
+// TestIndexMode tests that names are served from a fresh index and from blockstack-core otherwise
+func TestIndexMode(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	index := &indexer.Config{
+		URLs:                 []string{core.URL()},
+		IndexMethod:          "byName",
+		Store:                "memory",
+		ConcurrentPageFetch:  2,
+		ClientUpdateInterval: 60,
+		DBFlushInterval:      10 * time.Millisecond,
+		NamePageWorkers:      2,
+		ResolveWorkers:       2,
+		DBWorkers:            2,
+		ProfileTimeout:       100 * time.Millisecond,
+	}
+	// expiring.id expires 3 blocks after the index is built
+	registered := blockstacktest.FixtureLastBlock + 3 - core.Namespaces["id"].Record.Lifetime
+	core.AddName("expiring.id", "1Bv2vJMqBLrm7pRGTsMqDeoKb8bf6fsBPe", registered)
+	core.UpdateZonefile("expiring.id", registered+1, blockstacktest.FixtureZonefile("expiring.id", "https://example.com/profile.json"))
+	h := testHandlers(Config{Index: index, MaxIndexLag: 5}, core)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		h.Indexer.Stop(ctx)
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !h.indexFresh() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the index")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Core moves on, the index still has the old owner
+	owner := core.Records["muneeb.id"].Record.Address
+	core.TransferName("muneeb.id", "1Bv2vJMqBLrm7pRGTsMqDeoKb8bf6fsBPe", blockstacktest.FixtureLastBlock)
+	calls := core.Calls("get_name_blockchain_record")
+	if addr := nameAddress(t, get(h, "/v1/names/muneeb.id")); addr != owner {
+		t.Errorf("expected muneeb.id from the index owned by %s, got %s", owner, addr)
+	}
+	if core.Calls("get_name_blockchain_record") != calls {
+		t.Errorf("expected an indexed name not to be looked up on blockstack-core")
+	}
+	rec := get(h, "/v1/addresses/bitcoin/"+owner)
+	if !strings.Contains(rec.Body.String(), `"muneeb.id"`) {
+		t.Errorf("expected the indexed owner of muneeb.id, got %s", rec.Body.String())
+	}
+
+	// Names missing from the index are looked up on blockstack-core
+	core.AddName("newname.id", owner, blockstacktest.FixtureLastBlock)
+	rec = get(h, "/v1/names/newname.id")
+	expectStatus(t, "/v1/names/newname.id", rec, http.StatusOK, "")
+	if addr := nameAddress(t, rec); addr != owner {
+		t.Errorf("expected newname.id from blockstack-core, got %s", rec.Body.String())
+	}
+	expectStatus(t, "/v1/names/doesnotexist.id", get(h, "/v1/names/doesnotexist.id"), http.StatusNotFound, CodeNotFound)
+
+	// An index more than MaxIndexLag blocks behind the tip is not used
+	h.Lock()
+	h.lastBlock = blockstacktest.FixtureLastBlock + 6
+	h.Unlock()
+	if h.indexFresh() {
+		t.Errorf("expected an index 6 blocks behind not to be fresh")
+	}
+	if addr := nameAddress(t, get(h, "/v1/names/muneeb.id")); addr == owner {
+		t.Errorf("expected muneeb.id from blockstack-core once the index is behind")
+	}
+	h.Lock()
+	h.lastBlock = blockstacktest.FixtureLastBlock + 5
+	h.Unlock()
+	if !h.indexFresh() {
+		t.Errorf("expected an index 5 blocks behind to be fresh")
+	}
+
+	// Names that expired since they were indexed are not served as registered
+	var name map[string]interface{}
+	calls = core.Calls("get_name_blockchain_record")
+	if err := json.Unmarshal(get(h, "/v1/names/expiring.id").Body.Bytes(), &name); err != nil || name["status"] != blockstack.StatusInGracePeriod {
+		t.Errorf("expected expiring.id to be in its grace period at the tip, got %v", name["status"])
+	}
+	if core.Calls("get_name_blockchain_record") != calls {
+		t.Errorf("expected expiring.id to be served from the index")
+	}
+	var user V2GetUserProfileResponse
+	if err := json.Unmarshal(get(h, "/v2/users/expiring.id").Body.Bytes(), &user); err != nil || !user["expiring.id"].Expired {
+		t.Errorf("expected expiring.id to be expired at the tip")
+	}
+
+	// Core being down is a 503 for names the index can not answer
+	core.SetUnavailable(true)
+	if addr := nameAddress(t, get(h, "/v1/names/muneeb.id")); addr != owner {
+		t.Errorf("expected muneeb.id from the index while blockstack-core is down")
+	}
+	expectStatus(t, "/v1/names/newname.id", get(h, "/v1/names/newname.id"), http.StatusServiceUnavailable, CodeUpstreamUnavailable)
+}
@@ -3,7 +3,6 @@ package api
 import (
 	"encoding/json"
 	// "fmt"
-	"log"
 
 	"github.com/blockstack/blockstack.go/indexer"
 )
@@ -17,6 +16,8 @@ type V1GetNameResponse struct {
 	Status       string `json:"status"`
 	Zonefile     string `json:"zonefile"`
 	ZonefileHash string `json:"zonefile_hash"`
+	// Seqn is the sequence number of a subdomain
+	Seqn *int `json:"seqn,omitempty"`
 }
 
 // V1GetNameResponse is the response for the /v1/names/:name
@@ -28,13 +29,15 @@ type V1GetNameNoZResponse struct {
 	Status       string            `json:"status"`
 	Zonefile     map[string]string `json:"zonefile"`
 	ZonefileHash string            `json:"zonefile_hash"`
+	// Seqn is the sequence number of a subdomain
+	Seqn *int `json:"seqn,omitempty"`
 }
 
 // JSON proves a JSON output for ResponseWritert for ResponseWriter
 func (r V1GetNameResponse) JSON() []byte {
 	byt, err := json.Marshal(r)
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
 	return byt
 }
@@ -43,7 +46,7 @@ func (r V1GetNameResponse) JSON() []byte {
 func (r V1GetNameNoZResponse) JSON() []byte {
 	byt, err := json.Marshal(r)
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
 	return byt
 }
@@ -82,7 +85,7 @@ type Transaction struct {
 func (r Transaction) JSON() []byte {
 	byt, err := json.Marshal(r)
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
 	return byt
 }
@@ -95,7 +98,7 @@ type V1GetNameHistoryResponse map[int][]Transaction
 func (r V1GetNameHistoryResponse) JSON() []byte {
 	byt, err := json.Marshal(r)
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
 	return byt
 }
@@ -108,36 +111,36 @@ type V1GetNamesInNamespaceResponse []string
 func (r V1GetNamesInNamespaceResponse) JSON() []byte {
 	byt, err := json.Marshal(r)
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
 	return byt
 }
 
-// V2GetUserProfileResponse holds the response for the /v2/users/{name} route
-// NOTE: This is the big one
-// type V2GetUserProfileResponse struct{}
+// V2GetUserProfileResponse holds the response for the /v2/users/{name} route,
+// it is keyed by the name requested
+type V2GetUserProfileResponse map[string]V2GetUserProfile
 
 // JSON proves a JSON output for ResponseWriter
 func (r V2GetUserProfileResponse) JSON() []byte {
 	byt, err := json.Marshal(r)
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
 	return byt
 }
 
-type V2GetUserProfileResponse map[string]V2GetUserProfile
-
+// V2GetUserProfile is the profile of one name. Profile is the claim of the
+// profile token or the converted legacy profile, null if it could not be
+// fetched, and ZoneFile the parsed zonefile
 type V2GetUserProfile struct {
-	Expired       string          `json:"expired"`
-	Profile       indexer.Profile `json:"profile"`
-	Verifications []struct {
-		Identifier string `json:"identifier"`
-		ProofURL   string `json:"proof_url"`
-		Service    string `json:"service"`
-		Valid      bool   `json:"valid"`
-	} `json:"verifications"`
-	ZoneFile indexer.Zonefile `json:"zone_file"`
+	Expired       bool                        `json:"expired"`
+	Profile       json.RawMessage             `json:"profile"`
+	Verifications []indexer.ProofVerification `json:"verifications"`
+	ZoneFile      json.RawMessage             `json:"zone_file"`
+	// Verification is the outcome of checking the profile token against the owner
+	Verification *indexer.ProfileVerification `json:"verification,omitempty"`
+	// ProfileError is why the profile could not be fetched
+	ProfileError *indexer.ProfileError `json:"profile_error,omitempty"`
 }
 
 // V1GetNameOpsAtHeightResponse holds the response for the /v1/blockchains/bitcoin/operations/:blockHeight route
@@ -177,7 +180,7 @@ type V1GetNameOpsAtHeightResponse []struct {
 func (r V1GetNameOpsAtHeightResponse) JSON() []byte {
 	byt, err := json.Marshal(r)
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
 	return byt
 }
@@ -192,7 +195,7 @@ type V1GetNamesOwnedByAddressResponse struct {
 func (r V1GetNamesOwnedByAddressResponse) JSON() []byte {
 	byt, err := json.Marshal(r)
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
 	return byt
 }
@@ -207,7 +210,7 @@ type V1GetZonefileResponse struct {
 func (r V1GetZonefileResponse) JSON() []byte {
 	byt, err := json.Marshal(r)
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
 	return byt
 }
@@ -272,7 +275,7 @@ type V1GetNamespaceBlockchainRecordResponse struct {
 func (r V1GetNamespaceBlockchainRecordResponse) JSON() []byte {
 	byt, err := json.Marshal(r)
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
 	return byt
 }
@@ -285,7 +288,7 @@ type V1GetNamespacesResponse []string
 func (r V1GetNamespacesResponse) JSON() []byte {
 	byt, err := json.Marshal(r)
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
 	return byt
 }
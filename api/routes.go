@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"net/http"
 
-	"github.com/blockstack/blockstack.go/blockstack"
 	"github.com/gorilla/mux"
 	// "github.com/blockstack/blockstack.go/indexer"
 )
@@ -30,8 +29,12 @@ func (r Routes) Routes() []string {
 }
 
 // NewRouter returns a router instance to be served
-func NewRouter(conf blockstack.ServerConfig) *mux.Router {
-	h := NewHandlers(conf)
+func NewRouter(conf Config) *mux.Router {
+	return newRouter(NewHandlers(conf))
+}
+
+// newRouter routes requests to the handlers of h
+func newRouter(h *Handlers) *mux.Router {
 	routes := Routes{
 		// // NOTE: Testing Route, Remove
 		// Route{
@@ -111,7 +114,8 @@ func NewRouter(conf blockstack.ServerConfig) *mux.Router {
 
 	router := mux.NewRouter().StrictSlash(true)
 	for _, route := range routes {
-		router.Methods(route.Method).Path(route.Pattern).Name(route.Name).Handler(route.HandlerFunc)
+		router.Methods(route.Method).Path(route.Pattern).Name(route.Name).Handler(recoverer(route.HandlerFunc))
 	}
+	router.NotFoundHandler = http.HandlerFunc(notFound)
 	return router
 }
@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+)
+
+// consensusHashesPageSize is the most blocks blockstack-core returns consensus hashes for per call
+const consensusHashesPageSize = 32
+
+// Source is where audited hashes come from, a *blockstack.Client or a recorded *Fixture
+type Source interface {
+	GetConsensusHashesContext(ctx context.Context, blocks []int) (blockstack.GetConsensusHashesResult, blockstack.Error)
+	GetNameOpsHashAtContext(ctx context.Context, block int) (blockstack.GetNameOpsHashAtResult, blockstack.Error)
+}
+
+// Mismatch is a block whose reported consensus hash is not the one computed from the node's own hashes
+type Mismatch struct {
+	Block    int    `json:"block"`
+	OpsHash  string `json:"ops_hash"`
+	Reported string `json:"reported"`
+	Computed string `json:"computed"`
+}
+
+// Report is the outcome of an Audit
+type Report struct {
+	Start      int        `json:"start"`
+	End        int        `json:"end"`
+	Checked    int        `json:"checked"`
+	Mismatches []Mismatch `json:"mismatches"`
+}
+
+// JSON returns the JSON representation of Report
+func (r Report) JSON() string {
+	byt, err := json.Marshal(r)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return string(byt)
+}
+
+// PrettyJSON returns the Pretty Printed JSON representation of Report
+func (r Report) PrettyJSON() string {
+	byt, err := json.MarshalIndent(r, "", "    ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return string(byt)
+}
+
+// Audit recomputes the consensus hash of every block from start to end from
+// the ops hash and earlier consensus hashes src reports, and returns the blocks
+// where that is not the consensus hash src reports
+func Audit(ctx context.Context, src Source, start, end int) (Report, error) {
+	report := Report{Start: start, End: end, Mismatches: []Mismatch{}}
+	hashes, ops, err := fetch(ctx, src, start, end)
+	if err != nil {
+		return report, err
+	}
+	for block := start; block <= end; block++ {
+		computed, err := ConsensusHash(ops[block], prevHashes(hashes, block))
+		if err != nil {
+			return report, fmt.Errorf("block %d: %v", block, err)
+		}
+		report.Checked++
+		if computed != hashes[block] {
+			report.Mismatches = append(report.Mismatches, Mismatch{Block: block, OpsHash: ops[block], Reported: hashes[block], Computed: computed})
+		}
+	}
+	return report, nil
+}
+
+// Record fetches the hashes an Audit from start to end needs from src
+func Record(ctx context.Context, src Source, start, end int) (*Fixture, error) {
+	hashes, ops, err := fetch(ctx, src, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &Fixture{ConsensusHashes: hashes, OpsHashes: ops}, nil
+}
+
+// fetch returns the consensus hashes at every block from start to end and
+// their PrevBlocks, and the ops hashes from start to end
+func fetch(ctx context.Context, src Source, start, end int) (map[int]string, map[int]string, error) {
+	if start < blockstack.StartBlock || start > end {
+		return nil, nil, fmt.Errorf("audit: invalid block range %d to %d, blocks start at %d", start, end, blockstack.StartBlock)
+	}
+	needed := make(map[int]bool)
+	for block := start; block <= end; block++ {
+		needed[block] = true
+		for _, prev := range PrevBlocks(block, blockstack.StartBlock) {
+			needed[prev] = true
+		}
+	}
+	blocks := make([]int, 0, len(needed))
+	for block := range needed {
+		blocks = append(blocks, block)
+	}
+	sort.Ints(blocks)
+
+	hashes := make(map[int]string, len(blocks))
+	for lo := 0; lo < len(blocks); lo += consensusHashesPageSize {
+		hi := lo + consensusHashesPageSize
+		if hi > len(blocks) {
+			hi = len(blocks)
+		}
+		res, err := src.GetConsensusHashesContext(ctx, blocks[lo:hi])
+		if err != nil {
+			return nil, nil, err
+		}
+		for block, hash := range res.ConsensusHashes {
+			hashes[block] = hash
+		}
+	}
+
+	ops := make(map[int]string, end-start+1)
+	for block := start; block <= end; block++ {
+		res, err := src.GetNameOpsHashAtContext(ctx, block)
+		if err != nil {
+			return nil, nil, err
+		}
+		ops[block] = res.OpsHash
+	}
+	return hashes, ops, nil
+}
+
+// prevHashes returns the consensus hashes at the PrevBlocks of block. Like
+// blockstack-core it stops at the first block without one
+func prevHashes(hashes map[int]string, block int) []string {
+	var out []string
+	for _, prev := range PrevBlocks(block, blockstack.StartBlock) {
+		hash, ok := hashes[prev]
+		if !ok || hash == "" {
+			break
+		}
+		out = append(out, hash)
+	}
+	return out
+}
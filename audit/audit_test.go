@@ -0,0 +1,218 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+	"github.com/blockstack/blockstack.go/blockstack/blockstacktest"
+)
+
+// fixtureEnd is the last block in testdata/synthetic.json, which starts at blockstack.StartBlock
+const fixtureEnd = blockstack.StartBlock + 127
+
+// emptyOpsHash is the ops hash virtualchain gives a block without name operations
+const emptyOpsHash = "5df6e0e2761359d30a8275058e299fcc0381534545f55cf43e41983f5d4c9456"
+
+// loadFixture returns the hashes in testdata/synthetic.json. They are not
+// recorded from a node but generated by testdata/gen_synthetic.py, a port of
+// virtualchain that shares no code with this package
+func loadFixture(t *testing.T) *Fixture {
+	f, err := LoadFixture(filepath.Join("testdata", "synthetic.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// TestMerkleRoot tests the merkle tree against the transactions of bitcoin block 100000
+func TestMerkleRoot(t *testing.T) {
+	root, err := MerkleRoot([]string{
+		"8c14f0db3df150123e6f3dbbf30f8b955a8249b62ac1d1ff16284aefa3d06d87",
+		"fff2525b8931402dd09222c50775608f75787bd2b87e56995a7bdd30f79702c4",
+		"6359f0868171b1d194cbee1af2f16ea598ae8fad666d9b012c8ed2b79a236ec4",
+		"e9a66845e05d5abc0ad04ec80f774a7e585c6e8db975962d069a522137b80c1d",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "f3e94742aca4b5ef85488dc37c06c3282295ffec960994b2c0d5ac2a25a95766"; root != expected {
+		t.Errorf("expected %s, got %s", expected, root)
+	}
+	if _, err := MerkleRoot(nil); err == nil {
+		t.Errorf("expected an error for an empty tree")
+	}
+}
+
+// TestPrevBlocks tests the geometric series of blocks mixed into a consensus hash
+func TestPrevBlocks(t *testing.T) {
+	first := blockstack.StartBlock
+	var tests = []struct {
+		block    int
+		expected []int
+	}{
+		{first, nil},
+		{first + 1, []int{first}},
+		{first + 5, []int{first + 4, first + 2}},
+		{first + 7, []int{first + 6, first + 4, first}},
+		{first + 16, []int{first + 15, first + 13, first + 9, first + 1}},
+	}
+	for _, tt := range tests {
+		if out := PrevBlocks(tt.block, first); !reflect.DeepEqual(out, tt.expected) {
+			t.Errorf("block %d: expected %v, got %v", tt.block, tt.expected, out)
+		}
+	}
+}
+
+// TestConsensusHash tests the parts of the rule a matching chain would not catch on its own
+func TestConsensusHash(t *testing.T) {
+	f := loadFixture(t)
+	block := blockstack.StartBlock + 100
+	var prev []string
+	for _, b := range PrevBlocks(block, blockstack.StartBlock) {
+		prev = append(prev, f.ConsensusHashes[b])
+	}
+
+	// The hashes are sorted before they are put in the tree
+	shuffled := append([]string{}, prev...)
+	sort.Sort(sort.Reverse(sort.StringSlice(shuffled)))
+	h, err := ConsensusHash(f.OpsHashes[block], shuffled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h != f.ConsensusHashes[block] {
+		t.Errorf("expected %s regardless of the order of the previous hashes, got %s", f.ConsensusHashes[block], h)
+	}
+
+	// Consensus hashes are 16 bytes
+	if len(h) != 32 {
+		t.Errorf("expected a 16 byte consensus hash, got %s", h)
+	}
+
+	// Every previous hash changes the result
+	for n := range prev {
+		if h, _ := ConsensusHash(f.OpsHashes[block], prev[:n]); h == f.ConsensusHashes[block] {
+			t.Errorf("expected a different hash with only %d of %d previous hashes", n, len(prev))
+		}
+	}
+
+	if f.OpsHashes[blockstack.StartBlock] != emptyOpsHash {
+		t.Errorf("expected the first block to have no name operations")
+	}
+}
+
+// TestAuditFixture tests that the synthetic chain recomputes
+func TestAuditFixture(t *testing.T) {
+	report, err := Audit(context.Background(), loadFixture(t), blockstack.StartBlock, fixtureEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Checked != 128 || len(report.Mismatches) != 0 {
+		t.Errorf("expected 128 blocks to check out, got %s", report.JSON())
+	}
+}
+
+// TestAuditRecorded audits the ranges recorded from nodes with
+// blockstackd-cli audit-consensus --record and saved in testdata/recorded
+func TestAuditRecorded(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "recorded", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Skip("no recordings in testdata/recorded")
+	}
+	for _, path := range paths {
+		f, err := LoadFixture(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start, end := f.opsRange()
+		report, err := Audit(context.Background(), f, start, end)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if len(report.Mismatches) != 0 {
+			t.Errorf("%s: expected the hashes recorded from %s to check out, got %s", path, f.Node, report.JSON())
+		}
+	}
+}
+
+// TestAuditTamperedOpsHash tests that a changed ops hash is flagged at its block only
+func TestAuditTamperedOpsHash(t *testing.T) {
+	f := loadFixture(t)
+	block := blockstack.StartBlock + 100
+	f.OpsHashes[block] = "0000000000000000000000000000000000000000000000000000000000000000"
+
+	report, err := Audit(context.Background(), f, blockstack.StartBlock+64, fixtureEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Mismatches) != 1 || report.Mismatches[0].Block != block || report.Mismatches[0].Reported != f.ConsensusHashes[block] {
+		t.Errorf("expected a mismatch at %d only, got %s", block, report.JSON())
+	}
+}
+
+// TestAuditTamperedConsensus tests that a changed consensus hash is flagged at its
+// block and at every later block that mixes it in
+func TestAuditTamperedConsensus(t *testing.T) {
+	f := loadFixture(t)
+	block := blockstack.StartBlock + 100
+	f.ConsensusHashes[block] = "00000000000000000000000000000000"
+
+	report, err := Audit(context.Background(), f, blockstack.StartBlock+96, fixtureEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var flagged []int
+	for _, m := range report.Mismatches {
+		flagged = append(flagged, m.Block)
+	}
+	if expected := []int{block, block + 1, block + 3, block + 7, block + 15}; !reflect.DeepEqual(flagged, expected) {
+		t.Errorf("expected mismatches at %v, got %v", expected, flagged)
+	}
+}
+
+// TestAuditClient tests auditing a node and recording what it reported
+func TestAuditClient(t *testing.T) {
+	f := loadFixture(t)
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	core.Lock()
+	for block, hash := range f.ConsensusHashes {
+		core.ConsensusHashes[block] = hash
+	}
+	for block, hash := range f.OpsHashes {
+		core.OpsHashes[block] = hash
+	}
+	core.Unlock()
+
+	start := blockstack.StartBlock + 120
+	report, err := Audit(context.Background(), core.Client(), start, fixtureEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Checked != 8 || len(report.Mismatches) != 0 {
+		t.Errorf("expected 8 blocks to check out, got %s", report.JSON())
+	}
+
+	// A node that is not on the recorded chain fails every block
+	other := blockstacktest.NewCore()
+	defer other.Close()
+	recorded, err := Record(context.Background(), other.Client(), start, fixtureEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err = Audit(context.Background(), recorded, start, fixtureEnd)
+	if err != nil || len(report.Mismatches) != 8 {
+		t.Errorf("expected every block of the fake chain to mismatch, got %v %s", err, report.JSON())
+	}
+
+	if _, err := Audit(context.Background(), f, fixtureEnd, start); err == nil {
+		t.Errorf("expected an error for a reversed range")
+	}
+}
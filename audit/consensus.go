@@ -0,0 +1,98 @@
+// Package audit recomputes the consensus hashes a blockstack-core node reports.
+//
+// The consensus hash at a block is derived from the hash of the name
+// operations at that block (the ops hash) and the consensus hashes at the
+// blocks before it in a geometric series: block-1, block-3, block-7, block-15
+// and so on back to the first block. The hashes are sorted, put in a merkle
+// tree, and the consensus hash is the first 16 bytes of the hash160 of the root.
+// A node that reports a consensus hash that does not follow from its own ops
+// hash and earlier consensus hashes has a corrupt or tampered database.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	"golang.org/x/crypto/ripemd160"
+)
+
+// PrevBlocks returns the blocks whose consensus hashes are mixed into the
+// consensus hash at block: block-(2^i-1) for i from 1, none before first
+func PrevBlocks(block, first int) []int {
+	var out []int
+	for step := 1; block-step >= first; step = step*2 + 1 {
+		out = append(out, block-step)
+	}
+	return out
+}
+
+// ConsensusHash returns the consensus hash of a block with opsHash, given the
+// consensus hashes at its PrevBlocks
+func ConsensusHash(opsHash string, prev []string) (string, error) {
+	hashes := append([]string{opsHash}, prev...)
+	sort.Strings(hashes)
+	root, err := MerkleRoot(hashes)
+	if err != nil {
+		return "", err
+	}
+	byt, err := hex.DecodeString(root)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash160(byt)[:16]), nil
+}
+
+// MerkleRoot returns the root of the bitcoin merkle tree over the hex encoded
+// hashes. Like bitcoin txids the hashes and the root are hex encoded with
+// their bytes reversed
+func MerkleRoot(hashes []string) (string, error) {
+	if len(hashes) == 0 {
+		return "", errors.New("audit: no hashes to build a merkle tree from")
+	}
+	row := make([][]byte, len(hashes))
+	for i, h := range hashes {
+		byt, err := hex.DecodeString(h)
+		if err != nil {
+			return "", fmt.Errorf("audit: invalid hash %q: %v", h, err)
+		}
+		row[i] = reverse(byt)
+	}
+	for len(row) > 1 {
+		if len(row)%2 == 1 {
+			row = append(row, row[len(row)-1])
+		}
+		next := make([][]byte, 0, len(row)/2)
+		for i := 0; i < len(row); i += 2 {
+			next = append(next, doubleSHA256(append(append([]byte{}, row[i]...), row[i+1]...)))
+		}
+		row = next
+	}
+	return hex.EncodeToString(reverse(row[0])), nil
+}
+
+// doubleSHA256 returns SHA256(SHA256(byt))
+func doubleSHA256(byt []byte) []byte {
+	first := sha256.Sum256(byt)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+// hash160 returns RIPEMD160(SHA256(byt))
+func hash160(byt []byte) []byte {
+	sha := sha256.Sum256(byt)
+	h := ripemd160.New()
+	h.Write(sha[:])
+	return h.Sum(nil)
+}
+
+// reverse returns a reversed copy of byt
+func reverse(byt []byte) []byte {
+	out := make([]byte, len(byt))
+	for i, b := range byt {
+		out[len(byt)-1-i] = b
+	}
+	return out
+}
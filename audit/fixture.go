@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+)
+
+// Fixture is a recording of the hashes a node reported, it can be audited
+// again later as a Source
+type Fixture struct {
+	Node            string         `json:"node,omitempty"`
+	ConsensusHashes map[int]string `json:"consensus_hashes"`
+	OpsHashes       map[int]string `json:"ops_hashes"`
+}
+
+// LoadFixture reads a Fixture written by Save
+func LoadFixture(path string) (*Fixture, error) {
+	byt, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f Fixture
+	if err := json.Unmarshal(byt, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Save writes f to path as JSON
+func (f *Fixture) Save(path string) error {
+	byt, err := json.MarshalIndent(f, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(byt, '\n'), 0644)
+}
+
+// opsRange returns the first and last block f has an ops hash for
+func (f *Fixture) opsRange() (int, int) {
+	start, end := 0, 0
+	for block := range f.OpsHashes {
+		if start == 0 || block < start {
+			start = block
+		}
+		if block > end {
+			end = block
+		}
+	}
+	return start, end
+}
+
+// GetConsensusHashesContext returns the recorded consensus hashes at blocks
+func (f *Fixture) GetConsensusHashesContext(ctx context.Context, blocks []int) (blockstack.GetConsensusHashesResult, blockstack.Error) {
+	res := blockstack.GetConsensusHashesResult{Status: true, ConsensusHashes: make(map[int]string)}
+	for _, block := range blocks {
+		if hash, ok := f.ConsensusHashes[block]; ok {
+			res.ConsensusHashes[block] = hash
+		}
+	}
+	return res, nil
+}
+
+// GetNameOpsHashAtContext returns the recorded ops hash at block
+func (f *Fixture) GetNameOpsHashAtContext(ctx context.Context, block int) (blockstack.GetNameOpsHashAtResult, blockstack.Error) {
+	hash, ok := f.OpsHashes[block]
+	if !ok {
+		return blockstack.GetNameOpsHashAtResult{}, blockstack.StatusError{RPC: "get_nameops_hash_at"}
+	}
+	return blockstack.GetNameOpsHashAtResult{Status: true, OpsHash: hash}, nil
+}
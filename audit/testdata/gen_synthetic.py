@@ -0,0 +1,72 @@
+#!/usr/bin/env python3
+"""Generates synthetic.json, a chain of consensus hashes for the audit tests.
+
+The hashes are not recorded from a node. They are computed by this port of
+virtualchain's StateEngine.make_ops_snapshot and make_snapshot_from_ops_hash
+and pybitcoin's MerkleTree, which shares no code with the Go implementation.
+Blocks without name operations get the ops hash of an empty block, a few
+blocks get the ops hash of some made up serialized operations.
+
+Run it from this directory: python3 gen_synthetic.py
+"""
+import binascii
+import hashlib
+import json
+
+FIRST_BLOCK = 373601
+BLOCKS = 128
+
+
+def double_sha256(byt):
+    return hashlib.sha256(hashlib.sha256(byt).digest()).digest()
+
+
+def hash160(byt):
+    return hashlib.new("ripemd160", hashlib.sha256(byt).digest()).digest()
+
+
+def merkle_root(hex_hashes):
+    row = [binascii.unhexlify(h)[::-1] for h in hex_hashes]
+    while len(row) > 1:
+        if len(row) % 2 == 1:
+            row.append(row[-1])
+        row = [double_sha256(row[i] + row[i + 1]) for i in range(0, len(row), 2)]
+    return binascii.hexlify(row[0][::-1]).decode()
+
+
+def ops_hash(serialized_ops):
+    hashes = [binascii.hexlify(double_sha256(op)).decode() for op in serialized_ops]
+    if not hashes:
+        hashes = [binascii.hexlify(double_sha256(b"")).decode()]
+    return merkle_root(hashes)
+
+
+def consensus_hash(ops, prev):
+    root = merkle_root(sorted(prev + [ops]))
+    return binascii.hexlify(hash160(binascii.unhexlify(root))[:16]).decode()
+
+
+def main():
+    consensus, ops = {}, {}
+    for block in range(FIRST_BLOCK, FIRST_BLOCK + BLOCKS):
+        serialized = []
+        if block % 7 == 0:
+            serialized = [("NAME_REGISTRATION:name%d-%d.id" % (block, n)).encode() for n in range(block % 5 + 1)]
+        ops[block] = ops_hash(serialized)
+
+        prev, step = [], 1
+        while block - step >= FIRST_BLOCK:
+            prev.append(consensus[block - step])
+            step = step * 2 + 1
+        consensus[block] = consensus_hash(ops[block], prev)
+
+    with open("synthetic.json", "w") as f:
+        json.dump({
+            "consensus_hashes": {str(b): h for b, h in consensus.items()},
+            "ops_hashes": {str(b): h for b, h in ops.items()},
+        }, f, indent=4)
+        f.write("\n")
+
+
+if __name__ == "__main__":
+    main()
@@ -1,15 +1,27 @@
 package blockstack_test
 
 import (
+	"context"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/blockstack/blockstack.go/blockstack"
+	"github.com/blockstack/blockstack.go/blockstack/blockstacktest"
 )
 
-var conf = blockstack.ServerConfig{
-	Address: "node.blockstack.org",
-	Port:    6263,
-	TLS:     true,
+// core is the fake blockstack-core node shared by the tests
+var core *blockstacktest.Core
+
+// conf points at core
+var conf blockstack.ServerConfig
+
+func TestMain(m *testing.M) {
+	core = blockstacktest.NewCore()
+	conf = core.Config()
+	code := m.Run()
+	core.Close()
+	os.Exit(code)
 }
 
 // TestPing tests the blockstack.Client.Ping method
@@ -137,7 +149,7 @@ func TestGetNumNames(t *testing.T) {
 	if err != nil {
 		t.Fail()
 	}
-	if res.Count <= 74000 {
+	if res.Count != len(core.Records) {
 		t.Fail()
 	}
 }
@@ -150,7 +162,7 @@ func TestGetAllNames(t *testing.T) {
 	if err != nil {
 		t.Fail()
 	}
-	if len(res.Names) != 100 {
+	if len(res.Names) != len(core.Records) {
 		t.Fail()
 	}
 }
@@ -176,7 +188,7 @@ func TestGetNamesInNamespace(t *testing.T) {
 	if err != nil {
 		t.Fail()
 	}
-	if len(res.Names) != 100 {
+	if len(res.Names) != 5 {
 		t.Fail()
 	}
 }
@@ -189,7 +201,7 @@ func TestGetNumNamesInNamespace(t *testing.T) {
 	if err != nil {
 		t.Fail()
 	}
-	if res.Count <= 74000 {
+	if res.Count != 5 {
 		t.Fail()
 	}
 }
@@ -211,11 +223,11 @@ func TestGetConsensusAt(t *testing.T) {
 func TestGetBlockFromConsensus(t *testing.T) {
 	t.Parallel()
 	bsk := blockstack.NewClient(conf)
-	res, err := bsk.GetBlockFromConsensus("28aaae61809c6292b187b5cd9a92aa25")
+	res, err := bsk.GetBlockFromConsensus(core.ConsensusAt(480004))
 	if err != nil {
 		t.Fail()
 	}
-	if res.BlockID == 480004 {
+	if res.BlockID != 480004 {
 		t.Fail()
 	}
 }
@@ -228,7 +240,7 @@ func TestGetAtlasPeers(t *testing.T) {
 	if err != nil {
 		t.Fail()
 	}
-	if len(res.Peers) <= 5 {
+	if len(res.Peers) != len(core.Peers) {
 		t.Fail()
 	}
 }
@@ -272,18 +284,45 @@ func TestGetNamespaceBlockchainRecord(t *testing.T) {
 	}
 }
 
-// TestGetZonefiles tests the blockstack.Client.TestGetZonefiles method
-// func TestGetZonefiles(t *testing.T) {
-// 	t.Parallel()
-// 	bsk := blockstack.NewClient(conf)
-// 	res, err := bsk.GetZonefiles()
-// 	if err != nil {
-// 		t.Fail()
-// 	}
-// 	if res.Lastblock == 12313 {
-// 		t.Fail()
-// 	}
-// }
+// TestGetZonefiles tests the blockstack.Client.GetZonefiles method
+func TestGetZonefiles(t *testing.T) {
+	t.Parallel()
+	bsk := blockstack.NewClient(conf)
+	rec, err := bsk.GetNameBlockchainRecord("muneeb.id")
+	if err != nil {
+		t.Fail()
+	}
+	res, err := bsk.GetZonefiles([]string{rec.Record.ValueHash})
+	if err != nil {
+		t.Fail()
+	}
+	if res.Decode()[rec.Record.ValueHash] != core.Zonefiles[rec.Record.ValueHash] {
+		t.Fail()
+	}
+}
+
+// TestGetZonefilesMismatch tests that zonefiles that do not match their hash are dropped
+func TestGetZonefilesMismatch(t *testing.T) {
+	t.Parallel()
+	tampered := blockstacktest.NewCore()
+	defer tampered.Close()
+	good := tampered.Records["muneeb.id"].Record.ValueHash
+	bad := tampered.Records["judecn.id"].Record.ValueHash
+	tampered.Zonefiles[bad] = "$ORIGIN judecn.id\n$TTL 3600\n_http._tcp IN URI 10 1 \"https://evil.example.com/profile.json\"\n"
+
+	res, err := tampered.Client().GetZonefiles([]string{good, bad})
+	hashErr, ok := err.(blockstack.ZonefileHashError)
+	if !ok || len(hashErr.Mismatched) != 1 || hashErr.Mismatched[0].Hash != bad {
+		t.Errorf("expected a ZonefileHashError for %s, got %v", bad, err)
+	}
+	zonefiles := res.Decode()
+	if _, ok := zonefiles[bad]; ok {
+		t.Errorf("expected the tampered zonefile to be dropped")
+	}
+	if zonefiles[good] != tampered.Zonefiles[good] {
+		t.Errorf("expected the verified zonefile to be returned")
+	}
+}
 
 // TestGetOpHistoryRows tests the blockstack.Client.GetOpHistoryRows method
 func TestGetOpHistoryRows(t *testing.T) {
@@ -319,7 +358,7 @@ func TestGetConsensusHashes(t *testing.T) {
 	if err != nil {
 		t.Fail()
 	}
-	if res.ConsensusHashes[480003] != "1b8536675c9248dfddf4669985d5359f" {
+	if res.ConsensusHashes[480003] != core.ConsensusAt(480003) {
 		t.Fail()
 	}
 }
@@ -349,3 +388,394 @@ func TestGetNumNameOpsAffectedAt(t *testing.T) {
 		t.Fail()
 	}
 }
+
+// TestGetNameBlockchainRecordNotFound tests that core errors are returned as blockstack.RPCError
+func TestGetNameBlockchainRecordNotFound(t *testing.T) {
+	t.Parallel()
+	bsk := blockstack.NewClient(conf)
+	_, err := bsk.GetNameBlockchainRecord("doesnotexist.id")
+	rpcErr, ok := err.(blockstack.RPCError)
+	if !ok || rpcErr.Err != "Not found." || rpcErr.RPC != "get_name_blockchain_record" {
+		t.Fail()
+	}
+}
+
+// TestCallError tests that an unreachable node returns a blockstack.CallError
+func TestCallError(t *testing.T) {
+	t.Parallel()
+	down := blockstacktest.NewCore()
+	defer down.Close()
+	down.SetUnavailable(true)
+	_, err := down.Client().GetInfo()
+	if _, ok := err.(blockstack.CallError); !ok {
+		t.Fail()
+	}
+}
+
+// TestSetError tests canned error responses from the fake node
+func TestSetError(t *testing.T) {
+	t.Parallel()
+	failing := blockstacktest.NewCore()
+	defer failing.Close()
+	failing.SetError("get_all_namespaces", "Database is locked")
+	_, err := failing.Client().GetAllNamespaces()
+	if rpcErr, ok := err.(blockstack.RPCError); !ok || rpcErr.Err != "Database is locked" {
+		t.Fail()
+	}
+}
+
+// TestContextCancel tests that a call is aborted when its context deadline passes
+func TestContextCancel(t *testing.T) {
+	t.Parallel()
+	slow := blockstacktest.NewCore()
+	release := make(chan struct{})
+	defer slow.Close()
+	defer close(release)
+	slow.Handle("getinfo", func(c *blockstacktest.Core, args []interface{}) (interface{}, error) {
+		<-release
+		return c.Info, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := slow.Client().GetInfoContext(ctx)
+	callErr, ok := err.(blockstack.CallError)
+	if !ok || callErr.Err != context.DeadlineExceeded {
+		t.Fail()
+	}
+	if time.Since(start) > time.Second {
+		t.Fail()
+	}
+}
+
+// TestNewClientWithCaller tests using the fake node in-process as a blockstack.Caller
+func TestNewClientWithCaller(t *testing.T) {
+	t.Parallel()
+	bsk := blockstack.NewClientWithCaller(conf, core)
+	res, err := bsk.GetNamesInNamespace("helloworld", 0, 100)
+	if err != nil {
+		t.Fail()
+	}
+	if len(res.Names) != 1 || res.Names[0] != "jude.helloworld" {
+		t.Fail()
+	}
+}
+
+// TestIndexingError tests that results from an indexing node are returned with a blockstack.IndexingError
+func TestIndexingError(t *testing.T) {
+	t.Parallel()
+	indexing := blockstacktest.NewCore()
+	defer indexing.Close()
+	indexing.Lock()
+	indexing.Info.Indexing = true
+	indexing.Unlock()
+	res, err := indexing.Client().GetNumNames()
+	if _, ok := err.(blockstack.IndexingError); !ok {
+		t.Fail()
+	}
+	if res.Count != len(indexing.Records) {
+		t.Fail()
+	}
+}
+
+// TestStatusError tests that status false results are returned with a blockstack.StatusError
+func TestStatusError(t *testing.T) {
+	t.Parallel()
+	unregistered := blockstacktest.NewCore()
+	defer unregistered.Close()
+	unregistered.Handle("get_name_blockchain_record", func(c *blockstacktest.Core, args []interface{}) (interface{}, error) {
+		return map[string]interface{}{"status": false, "lastblock": c.Info.LastBlockProcessed}, nil
+	})
+	res, err := unregistered.Client().GetNameBlockchainRecord("available.id")
+	if _, ok := err.(blockstack.StatusError); !ok {
+		t.Fail()
+	}
+	if res.Status || res.Lastblock != blockstacktest.FixtureLastBlock {
+		t.Fail()
+	}
+}
+
+// TestHooks tests that hooks run around every RPC method
+func TestHooks(t *testing.T) {
+	t.Parallel()
+	bsk := blockstack.NewClient(conf)
+	var before, after []string
+	var lastErr blockstack.Error
+	bsk.AddHooks(blockstack.Hooks{
+		Before: func(ctx context.Context, method string, args interface{}) context.Context {
+			before = append(before, method)
+			return nil
+		},
+		After: func(ctx context.Context, method string, args interface{}, took time.Duration, err blockstack.Error) {
+			after = append(after, method)
+			lastErr = err
+		},
+	})
+	bsk.Ping()
+	bsk.GetNameBlockchainRecord("doesnotexist.id")
+	if len(before) != 2 || len(after) != 2 || before[1] != "get_name_blockchain_record" || after[0] != "ping" {
+		t.Fail()
+	}
+	if _, ok := lastErr.(blockstack.RPCError); !ok {
+		t.Fail()
+	}
+}
+
+// TestPoolRoundRobin tests that calls are spread over every node
+func TestPoolRoundRobin(t *testing.T) {
+	t.Parallel()
+	a, b := blockstacktest.NewCore(), blockstacktest.NewCore()
+	defer a.Close()
+	defer b.Close()
+	pool := blockstack.NewPool(blockstack.PoolConfig{}, a.Client(), b.Client())
+	for i := 0; i < 4; i++ {
+		pool.Next().Ping()
+	}
+	if a.Calls("ping") != 2 || b.Calls("ping") != 2 {
+		t.Fail()
+	}
+}
+
+// TestPoolEjectAndReadmit tests that a failing node is ejected and re-admitted after a successful probe
+func TestPoolEjectAndReadmit(t *testing.T) {
+	t.Parallel()
+	up, down := blockstacktest.NewCore(), blockstacktest.NewCore()
+	defer up.Close()
+	defer down.Close()
+	down.SetUnavailable(true)
+	pool := blockstack.NewPool(blockstack.PoolConfig{MaxFailures: 2, EjectFor: 20 * time.Millisecond}, up.Client(), down.Client())
+
+	for i := 0; i < 6; i++ {
+		pool.Next().Ping()
+	}
+	if pool.Healthy() != 1 {
+		t.Fail()
+	}
+	for _, ns := range pool.Stats() {
+		if ns.URL == down.Config().String() && (!ns.Ejected || ns.Errors != 2) {
+			t.Fail()
+		}
+	}
+
+	down.SetUnavailable(false)
+	time.Sleep(30 * time.Millisecond)
+	pool.Next()
+	for i := 0; i < 100 && pool.Healthy() != 2; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if pool.Healthy() != 2 || down.Calls("getinfo") != 1 {
+		t.Fail()
+	}
+}
+
+// TestPoolNotFoundIsHealthy tests that errors returned by blockstack-core do not eject a node
+func TestPoolNotFoundIsHealthy(t *testing.T) {
+	t.Parallel()
+	pool := blockstack.NewPool(blockstack.PoolConfig{MaxFailures: 1}, blockstack.NewClient(conf))
+	pool.Next().GetNameBlockchainRecord("doesnotexist.id")
+	if pool.Healthy() != 1 {
+		t.Fail()
+	}
+}
+
+// TestPoolLeastLatency tests that the fastest node is preferred
+func TestPoolLeastLatency(t *testing.T) {
+	t.Parallel()
+	fast, slow := blockstacktest.NewCore(), blockstacktest.NewCore()
+	defer fast.Close()
+	defer slow.Close()
+	slow.Handle("ping", func(c *blockstacktest.Core, args []interface{}) (interface{}, error) {
+		time.Sleep(20 * time.Millisecond)
+		return map[string]string{"status": "alive"}, nil
+	})
+	fastClient, slowClient := fast.Client(), slow.Client()
+	pool := blockstack.NewPool(blockstack.PoolConfig{Strategy: blockstack.LeastLatency}, slowClient, fastClient)
+	slowClient.Ping()
+	fastClient.Ping()
+	if pool.Next() != fastClient {
+		t.Fail()
+	}
+}
+
+// TestParseStrategy tests that strategies round trip through their names
+func TestParseStrategy(t *testing.T) {
+	for _, s := range []blockstack.Strategy{blockstack.RoundRobin, blockstack.LeastLatency, blockstack.Weighted} {
+		p, err := blockstack.ParseStrategy(s.String())
+		if err != nil || p != s {
+			t.Fail()
+		}
+	}
+	if _, err := blockstack.ParseStrategy("random"); err == nil {
+		t.Fail()
+	}
+}
+
+// quorumPool returns a pool of n fresh fake nodes and the nodes
+func quorumPool(n int) (*blockstack.Pool, []*blockstacktest.Core) {
+	var cores []*blockstacktest.Core
+	var clients []*blockstack.Client
+	for i := 0; i < n; i++ {
+		c := blockstacktest.NewCore()
+		cores = append(cores, c)
+		clients = append(clients, c.Client())
+	}
+	return blockstack.NewPool(blockstack.PoolConfig{}, clients...), cores
+}
+
+// TestQuorumAgree tests that a record is returned when the nodes agree
+func TestQuorumAgree(t *testing.T) {
+	t.Parallel()
+	pool, cores := quorumPool(3)
+	for _, c := range cores {
+		defer c.Close()
+	}
+	res, err := pool.GetNameBlockchainRecordQuorum(context.Background(), "muneeb.id", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Record.Address != cores[0].Records["muneeb.id"].Record.Address {
+		t.Fail()
+	}
+
+	_, err = pool.GetNameBlockchainRecordQuorum(context.Background(), "doesnotexist.id", 3)
+	if rpcErr, ok := err.(blockstack.RPCError); !ok || rpcErr.Error() != "Not found." {
+		t.Fail()
+	}
+}
+
+// TestQuorumDisagree tests that a record one node disagrees on needs a smaller quorum
+func TestQuorumDisagree(t *testing.T) {
+	t.Parallel()
+	pool, cores := quorumPool(3)
+	for _, c := range cores {
+		defer c.Close()
+	}
+	cores[2].TransferName("muneeb.id", "1Bv2vJMqBLrm7pRGTsMqDeoKb8bf6fsBPe", blockstacktest.FixtureLastBlock)
+
+	_, err := pool.GetNameBlockchainRecordQuorum(context.Background(), "muneeb.id", 3)
+	qe, ok := err.(blockstack.QuorumError)
+	if !ok || qe.Agreed != 2 || qe.Nodes != 3 {
+		t.Fail()
+	}
+	res, err := pool.GetNameBlockchainRecordQuorum(context.Background(), "muneeb.id", 2)
+	if err != nil || res.Record.Address != cores[0].Records["muneeb.id"].Record.Address {
+		t.Fail()
+	}
+}
+
+// TestQuorumConsensusFork tests that nodes on a different fork do not count towards the quorum
+func TestQuorumConsensusFork(t *testing.T) {
+	t.Parallel()
+	pool, cores := quorumPool(2)
+	for _, c := range cores {
+		defer c.Close()
+	}
+	cores[1].Lock()
+	cores[1].ConsensusHashes[blockstacktest.FixtureLastBlock] = "00000000000000000000000000000000"
+	cores[1].Unlock()
+
+	_, err := pool.GetNameBlockchainRecordQuorum(context.Background(), "muneeb.id", 2)
+	if _, ok := err.(blockstack.QuorumError); !ok {
+		t.Fail()
+	}
+
+	// Nodes on different forks do not agree a name is missing either
+	_, err = pool.GetNameBlockchainRecordQuorum(context.Background(), "doesnotexist.id", 2)
+	if _, ok := err.(blockstack.QuorumError); !ok {
+		t.Errorf("expected a QuorumError for a missing name on a fork, got %v", err)
+	}
+}
+
+// TestNameStatus tests blockstack.NameStatus against the fixture names and edge cases
+func TestNameStatus(t *testing.T) {
+	t.Parallel()
+	bsk := blockstack.NewClient(conf)
+	for name, status := range map[string]string{"muneeb.id": blockstack.StatusRegistered, "expired.id": blockstack.StatusExpired} {
+		res, err := bsk.GetNameBlockchainRecord(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := blockstack.NameStatus(res); got != status {
+			t.Errorf("%s: expected %s, got %s", name, status, got)
+		}
+	}
+
+	var rec blockstack.GetNameBlockchainRecordResult
+	if blockstack.NameStatus(rec) != blockstack.StatusAvailable {
+		t.Errorf("expected a name without a record to be available")
+	}
+	rec.Status = true
+	rec.Lastblock = 1000
+	rec.Record.Opcode = "NAME_PREORDER"
+	if blockstack.NameStatus(rec) != blockstack.StatusPending {
+		t.Errorf("expected a preordered name to be pending")
+	}
+	rec.Record.Opcode = "NAME_REGISTRATION"
+	if blockstack.NameStatus(rec) != blockstack.StatusRegistered {
+		t.Errorf("expected a name that never expires to be registered")
+	}
+	rec.Record.ExpireBlock, rec.Record.RenewalDeadline = 900, 1100
+	if blockstack.NameStatus(rec) != blockstack.StatusInGracePeriod {
+		t.Errorf("expected a name before its renewal deadline to be in its grace period")
+	}
+	rec.Record.RenewalDeadline = 1000
+	if blockstack.NameStatus(rec) != blockstack.StatusExpired {
+		t.Errorf("expected a name past its renewal deadline to be expired")
+	}
+	rec.Record.Revoked = true
+	if blockstack.NameStatus(rec) != blockstack.StatusRevoked {
+		t.Errorf("expected a revoked name to be revoked")
+	}
+}
+
+// TestFindFork tests that the first block nodes disagree at is found along with the name operations that differ
+func TestFindFork(t *testing.T) {
+	t.Parallel()
+	cores := []*blockstacktest.Core{blockstacktest.NewCore(), blockstacktest.NewCore(), blockstacktest.NewCore()}
+	var clients []*blockstack.Client
+	for _, c := range cores {
+		defer c.Close()
+		clients = append(clients, c.Client())
+	}
+
+	// No fork
+	report, err := blockstack.FindFork(context.Background(), clients, blockstack.StartBlock, blockstacktest.FixtureLastBlock)
+	if err != nil || report.Forked || len(report.Nodes) != 3 {
+		t.Fatalf("expected no fork, got %v %s", err, report.JSON())
+	}
+
+	// The last node registered a name the others did not see
+	fork := 412345
+	cores[2].AddName("forked.id", "1Bv2vJMqBLrm7pRGTsMqDeoKb8bf6fsBPe", fork)
+	cores[2].Lock()
+	cores[2].ForkedAt = fork
+	cores[2].Unlock()
+
+	report, err = blockstack.FindFork(context.Background(), clients, blockstack.StartBlock, blockstacktest.FixtureLastBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Forked || report.Block != fork || report.LastAgreed != fork-1 {
+		t.Errorf("expected a fork at %d, got %s", fork, report.JSON())
+	}
+	if len(report.Nodes) != 3 || !report.Nodes[0].Majority || !report.Nodes[1].Majority || report.Nodes[2].Majority {
+		t.Fatalf("expected the last node to be outside the majority, got %s", report.JSON())
+	}
+	if report.Nodes[2].OpsHash == report.Nodes[0].OpsHash {
+		t.Errorf("expected the name ops hashes to differ at the fork")
+	}
+	if extra := report.Nodes[2].Extra; len(extra) != 1 || extra[0].Name != "forked.id" || len(report.Nodes[2].Missing) != 0 {
+		t.Errorf("expected forked.id as the only extra operation, got %s", report.JSON())
+	}
+
+	// A fork at the first block compared
+	report, err = blockstack.FindFork(context.Background(), clients, fork, blockstacktest.FixtureLastBlock)
+	if err != nil || !report.Forked || report.Block != fork || report.LastAgreed != 0 {
+		t.Errorf("expected a fork at the start block, got %v %s", err, report.JSON())
+	}
+
+	if _, err := blockstack.FindFork(context.Background(), clients[:1], blockstack.StartBlock, blockstacktest.FixtureLastBlock); err == nil {
+		t.Errorf("expected an error for a single node")
+	}
+}
@@ -0,0 +1,308 @@
+// Package blockstacktest provides an in-memory fake blockstack-core node for
+// testing code that uses the blockstack RPC client without network access.
+package blockstacktest
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+	"github.com/kolo/xmlrpc"
+)
+
+// errNotFound is the error blockstack-core returns for missing names and namespaces
+var errNotFound = errors.New("Not found.")
+
+// HandlerFunc answers an RPC method. The returned value is JSON encoded and
+// sent as the result, a returned error is sent as {"error": err.Error()}.
+// The Core is locked while the handler runs
+type HandlerFunc func(c *Core, args []interface{}) (interface{}, error)
+
+// Core is a fake blockstack-core node. It serves the canned data below over an
+// XML-RPC endpoint on a local httptest.Server and can also be used in-process
+// as a blockstack.Caller. Lock the Core when changing its fields while a test
+// is making calls against it
+type Core struct {
+	// Info is returned from getinfo, Info.LastBlockProcessed is the lastblock in every result
+	Info blockstack.GetInfoResult
+	// Records maps a name to the result of get_name_blockchain_record.
+	// The name ops, history and zonefile methods are all derived from the records
+	Records map[string]blockstack.GetNameBlockchainRecordResult
+	// Namespaces maps a namespace ID to the result of get_namespace_blockchain_record
+	Namespaces map[string]blockstack.GetNamespaceBlockchainRecordResult
+	// Zonefiles maps a zonefile hash to the raw zonefile
+	Zonefiles map[string]string
+	// ConsensusHashes overrides the consensus hash at a block height
+	ConsensusHashes map[int]string
+	// OpsHashes overrides the name ops hash at a block height
+	OpsHashes map[int]string
+	// ForkedAt, when set, makes the consensus and name ops hashes from that
+	// block on differ from those of a Core that is not forked
+	ForkedAt int
+	// Peers is returned from get_atlas_peers
+	Peers []string
+	// NameCost and NamespaceCost are returned from get_name_cost and get_namespace_cost
+	NameCost      int
+	NamespaceCost int
+	// Errors maps an RPC method to an error message that is returned instead of a result
+	Errors map[string]string
+	// Unavailable makes the HTTP endpoint answer every request with a 503
+	Unavailable bool
+	// Profiles maps a host and path, e.g. gaia.blockstack.org/hub/1A/profile.json,
+	// to a profile served to GET requests with that Host header
+	Profiles map[string]string
+
+	handlers map[string]HandlerFunc
+	calls    map[string]int
+	server   *httptest.Server
+
+	sync.Mutex
+}
+
+// NewCore returns a running fake node loaded with a small canned network.
+// Callers should call Close when finished to shut down the server
+func NewCore() *Core {
+	c := NewEmptyCore()
+	c.loadFixtures()
+	return c
+}
+
+// NewEmptyCore returns a running fake node with no names or namespaces
+func NewEmptyCore() *Core {
+	c := &Core{
+		Info: blockstack.GetInfoResult{
+			ServerAlive:        true,
+			LastBlockProcessed: blockstack.StartBlock,
+			LastBlockSeen:      blockstack.StartBlock,
+			ServerVersion:      "0.17.0.0",
+		},
+		Records:         make(map[string]blockstack.GetNameBlockchainRecordResult),
+		Namespaces:      make(map[string]blockstack.GetNamespaceBlockchainRecordResult),
+		Zonefiles:       make(map[string]string),
+		ConsensusHashes: make(map[int]string),
+		OpsHashes:       make(map[int]string),
+		Errors:          make(map[string]string),
+		Profiles:        make(map[string]string),
+		handlers:        make(map[string]HandlerFunc),
+		calls:           make(map[string]int),
+	}
+	c.server = httptest.NewServer(c)
+	return c
+}
+
+// Close shuts down the HTTP endpoint
+func (c *Core) Close() {
+	c.server.Close()
+}
+
+// URL returns the base URL of the HTTP endpoint
+func (c *Core) URL() string {
+	return c.server.URL
+}
+
+// Config returns the blockstack.ServerConfig pointing at the HTTP endpoint
+func (c *Core) Config() blockstack.ServerConfig {
+	u, err := url.Parse(c.server.URL)
+	if err != nil {
+		panic(err)
+	}
+	return blockstack.ServerConfig{Address: u.Hostname(), Port: u.Port(), Scheme: u.Scheme}
+}
+
+// Client returns a *blockstack.Client that talks to the fake node over HTTP
+func (c *Core) Client() *blockstack.Client {
+	return blockstack.NewClient(c.Config())
+}
+
+// Handle overrides the handler for an RPC method
+func (c *Core) Handle(method string, h HandlerFunc) {
+	c.Lock()
+	c.handlers[method] = h
+	c.Unlock()
+}
+
+// SetError makes the RPC method return msg as an error until cleared with an empty msg
+func (c *Core) SetError(method, msg string) {
+	c.Lock()
+	if msg == "" {
+		delete(c.Errors, method)
+	} else {
+		c.Errors[method] = msg
+	}
+	c.Unlock()
+}
+
+// SetUnavailable toggles 503 responses on the HTTP endpoint
+func (c *Core) SetUnavailable(down bool) {
+	c.Lock()
+	c.Unavailable = down
+	c.Unlock()
+}
+
+// Calls returns the number of times an RPC method has been called
+func (c *Core) Calls(method string) int {
+	c.Lock()
+	defer c.Unlock()
+	return c.calls[method]
+}
+
+// ConsensusAt returns the consensus hash the node reports at a block height
+func (c *Core) ConsensusAt(block int) string {
+	c.Lock()
+	defer c.Unlock()
+	return c.consensusAt(block)
+}
+
+// consensusAt returns the overridden consensus hash at block or a
+// deterministic one derived from the block height
+func (c *Core) consensusAt(block int) string {
+	if ch, ok := c.ConsensusHashes[block]; ok {
+		return ch
+	}
+	if c.ForkedAt > 0 && block >= c.ForkedAt {
+		sum := md5.Sum([]byte(fmt.Sprintf("consensus:%d:fork:%d", block, c.ForkedAt)))
+		return hex.EncodeToString(sum[:])
+	}
+	sum := md5.Sum([]byte(fmt.Sprintf("consensus:%d", block)))
+	return hex.EncodeToString(sum[:])
+}
+
+// opsHashAt returns the overridden name ops hash at block or a
+// deterministic one derived from the block height
+func (c *Core) opsHashAt(block int) string {
+	if oh, ok := c.OpsHashes[block]; ok {
+		return oh
+	}
+	if c.ForkedAt > 0 && block >= c.ForkedAt {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("ops:%d:fork:%d", block, c.ForkedAt)))
+		return hex.EncodeToString(sum[:])
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("ops:%d", block)))
+	return hex.EncodeToString(sum[:])
+}
+
+// ServeHTTP answers XML-RPC requests and GET requests for Profiles
+func (c *Core) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.Lock()
+	down := c.Unavailable
+	profile, found := c.Profiles[r.Host+r.URL.Path]
+	c.Unlock()
+	if down {
+		http.Error(w, "blockstack-core unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method == http.MethodGet {
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, profile)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	method, args, err := parseMethodCall(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/xml")
+	res, err := c.dispatch(method, args)
+	if err != nil {
+		w.Write(encodeFault(-32601, err.Error()))
+		return
+	}
+	w.Write(encodeResponse(res))
+}
+
+// Call satisfies blockstack.Caller so the fake node can be used without HTTP
+func (c *Core) Call(ctx context.Context, method string, args interface{}, reply interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Round trip the arguments through the XML-RPC encoding so handlers
+	// see the same types as they would over HTTP
+	var params []interface{}
+	if a, ok := args.([]interface{}); ok {
+		params = a
+	} else if args != nil {
+		params = []interface{}{args}
+	}
+	body, err := xmlrpc.EncodeMethodCall(method, params...)
+	if err != nil {
+		return err
+	}
+	method, decoded, err := parseMethodCall(body)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.dispatch(method, decoded)
+	if err != nil {
+		return err
+	}
+	out, ok := reply.(*string)
+	if !ok {
+		return fmt.Errorf("blockstacktest: reply must be *string, got %T", reply)
+	}
+	*out = res
+	return nil
+}
+
+// dispatch runs the handler for method and returns the JSON result.
+// An error is only returned for unknown methods
+func (c *Core) dispatch(method string, args []interface{}) (string, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.calls[method]++
+	if msg, ok := c.Errors[method]; ok {
+		return errorJSON(msg), nil
+	}
+
+	h, ok := c.handlers[method]
+	if !ok {
+		h, ok = defaultHandlers[method]
+	}
+	if !ok {
+		return "", fmt.Errorf("method %q is not supported", method)
+	}
+
+	res, err := h(c, args)
+	if err != nil {
+		return errorJSON(err.Error()), nil
+	}
+	byt, err := json.Marshal(res)
+	if err != nil {
+		return errorJSON(err.Error()), nil
+	}
+	return string(byt), nil
+}
+
+// errorJSON formats an error the way blockstack-core does
+func errorJSON(msg string) string {
+	byt, _ := json.Marshal(map[string]string{"error": msg})
+	return string(byt)
+}
+
+// ZonefileHash returns the hex encoded RIPEMD160(SHA256(zonefile)) used as a name's value_hash
+func ZonefileHash(zonefile string) string {
+	return blockstack.ZonefileHash(zonefile)
+}
@@ -0,0 +1,224 @@
+package blockstacktest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+)
+
+const (
+	// FixtureLastBlock is the chain tip of the canned network loaded by NewCore
+	FixtureLastBlock = 480010
+
+	// defaultLifetime is the lifetime in blocks of names in the id namespace
+	defaultLifetime = 52595
+
+	// renewalGracePeriod is the number of blocks after expiry a name can be renewed
+	renewalGracePeriod = 5000
+)
+
+// FixtureZonefile returns a standard blockstack zonefile pointing at a profile URL
+func FixtureZonefile(name, profileURL string) string {
+	return fmt.Sprintf("$ORIGIN %s\n$TTL 3600\n_http._tcp IN URI 10 1 \"%s\"\n", name, profileURL)
+}
+
+// txid returns a deterministic transaction ID for an operation
+func txid(parts ...interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprint(parts...)))
+	return hex.EncodeToString(sum[:])
+}
+
+// AddNamespace adds a ready namespace revealed at block
+func (c *Core) AddNamespace(ns string, block int, lifetime int) {
+	c.Lock()
+	defer c.Unlock()
+
+	var rec blockstack.GetNamespaceBlockchainRecordResult
+	rec.Record.NamespaceID = ns
+	rec.Record.BlockNumber = block
+	rec.Record.RevealBlock = block
+	rec.Record.ReadyBlock = block + 1
+	rec.Record.Ready = true
+	rec.Record.Lifetime = lifetime
+	rec.Record.Base = 4
+	rec.Record.Coeff = 250
+	rec.Record.Buckets = []int{6, 5, 4, 3, 2, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	rec.Record.NonalphaDiscount = 10
+	rec.Record.NoVowelDiscount = 10
+	rec.Record.Version = 1
+	rec.Record.Op = "&"
+	rec.Record.Opcode = "NAMESPACE_READY"
+	rec.Record.Txid = txid("ready", ns, block+1)
+	rec.Record.History = map[int][]blockstack.NamespaceTransaction{
+		block: {{
+			NamespaceID: ns,
+			BlockNumber: block,
+			RevealBlock: block,
+			Lifetime:    lifetime,
+			Base:        rec.Record.Base,
+			Coeff:       rec.Record.Coeff,
+			Buckets:     rec.Record.Buckets,
+			Version:     rec.Record.Version,
+			Op:          "*",
+			Opcode:      "NAMESPACE_REVEAL",
+			Txid:        txid("reveal", ns, block),
+		}},
+		block + 1: {{
+			NamespaceID: ns,
+			BlockNumber: block,
+			RevealBlock: block,
+			Lifetime:    lifetime,
+			Op:          "!",
+			Opcode:      "NAMESPACE_READY",
+			Txid:        rec.Record.Txid,
+		}},
+	}
+	c.Namespaces[ns] = rec
+}
+
+// AddName registers name to address at block. The namespace must already exist
+func (c *Core) AddName(name, address string, block int) {
+	c.Lock()
+	defer c.Unlock()
+
+	spl := strings.Split(name, ".")
+	ns := c.Namespaces[spl[len(spl)-1]]
+
+	var rec blockstack.GetNameBlockchainRecordResult
+	rec.Record.Name = name
+	rec.Record.NamespaceID = ns.Record.NamespaceID
+	rec.Record.NamespaceBlockNumber = ns.Record.BlockNumber
+	rec.Record.Address = address
+	rec.Record.BlockNumber = block
+	rec.Record.LastRenewed = block
+	rec.Record.ExpireBlock = block + ns.Record.Lifetime
+	rec.Record.RenewalDeadline = rec.Record.ExpireBlock + renewalGracePeriod
+	rec.Record.Opcode = "NAME_REGISTRATION"
+	rec.Record.Txid = txid("register", name, block)
+	rec.Record.History = make(map[int][]blockstack.Transaction)
+	c.Records[name] = rec
+	c.appendHistory(name, block, ":", "NAME_REGISTRATION")
+}
+
+// UpdateZonefile sets the zonefile for name with a NAME_UPDATE at block and returns its hash
+func (c *Core) UpdateZonefile(name string, block int, zonefile string) string {
+	c.Lock()
+	defer c.Unlock()
+
+	hash := ZonefileHash(zonefile)
+	c.Zonefiles[hash] = zonefile
+	rec := c.Records[name]
+	rec.Record.ValueHash = hash
+	rec.Record.Opcode = "NAME_UPDATE"
+	rec.Record.Txid = txid("update", name, block)
+	c.Records[name] = rec
+	c.appendHistory(name, block, "+", "NAME_UPDATE")
+	return hash
+}
+
+// AddProfile serves profile at profileURL to clients pointed at the Core
+func (c *Core) AddProfile(profileURL, profile string) {
+	c.Lock()
+	defer c.Unlock()
+
+	u, err := url.Parse(profileURL)
+	if err != nil {
+		panic(err)
+	}
+	c.Profiles[u.Host+u.Path] = profile
+}
+
+// TransferName transfers name to address with a NAME_TRANSFER at block
+func (c *Core) TransferName(name, address string, block int) {
+	c.Lock()
+	defer c.Unlock()
+
+	rec := c.Records[name]
+	rec.Record.Address = address
+	rec.Record.Opcode = "NAME_TRANSFER"
+	rec.Record.Txid = txid("transfer", name, block)
+	rec.Record.TransferSendBlockID = block
+	c.Records[name] = rec
+	c.appendHistory(name, block, ">", "NAME_TRANSFER")
+}
+
+// RenewName renews name with a NAME_RENEWAL at block
+func (c *Core) RenewName(name string, block int) {
+	c.Lock()
+	defer c.Unlock()
+
+	rec := c.Records[name]
+	lifetime := c.Namespaces[rec.Record.NamespaceID].Record.Lifetime
+	rec.Record.LastRenewed = block
+	rec.Record.ExpireBlock = block + lifetime
+	rec.Record.RenewalDeadline = rec.Record.ExpireBlock + renewalGracePeriod
+	rec.Record.Opcode = "NAME_RENEWAL"
+	rec.Record.Txid = txid("renew", name, block)
+	c.Records[name] = rec
+	c.appendHistory(name, block, ":", "NAME_RENEWAL")
+}
+
+// appendHistory records the current state of a name as an operation at block
+func (c *Core) appendHistory(name string, block int, op, opcode string) {
+	rec := c.Records[name]
+	rec.Record.History[block] = append(rec.Record.History[block], blockstack.Transaction{
+		Name:                 name,
+		Address:              rec.Record.Address,
+		BlockNumber:          rec.Record.BlockNumber,
+		ConsensusHash:        c.consensusAt(block - 1),
+		FirstRegistered:      rec.Record.BlockNumber,
+		LastCreationOp:       ":",
+		LastRenewed:          rec.Record.LastRenewed,
+		NamespaceBlockNumber: rec.Record.NamespaceBlockNumber,
+		Op:                   op,
+		Opcode:               opcode,
+		Txid:                 rec.Record.Txid,
+		ValueHash:            rec.Record.ValueHash,
+		Vtxindex:             len(rec.Record.History[block]),
+	})
+	c.Records[name] = rec
+}
+
+// loadFixtures loads the canned network returned by NewCore
+func (c *Core) loadFixtures() {
+	c.Info.LastBlockProcessed = FixtureLastBlock
+	c.Info.LastBlockSeen = FixtureLastBlock
+	c.NameCost = 6400000
+	c.NamespaceCost = 4000000000
+	c.Peers = []string{
+		"node.blockstack.org:6264",
+		"node2.blockstack.org:6264",
+		"node3.blockstack.org:6264",
+	}
+
+	c.AddNamespace("id", blockstack.StartBlock, defaultLifetime)
+	c.AddNamespace("helloworld", 420000, defaultLifetime)
+
+	c.AddName("muneeb.id", "17hEAjUUWp5wN9SEGYqxpdtjHKzWVkmHEo", 373821)
+	c.UpdateZonefile("muneeb.id", 374000, FixtureZonefile("muneeb.id", "https://gaia.blockstack.org/hub/17hEAjUUWp5wN9SEGYqxpdtjHKzWVkmHEo/0/profile.json"))
+	c.UpdateZonefile("muneeb.id", 400000, FixtureZonefile("muneeb.id", "https://gaia.blockstack.org/hub/17hEAjUUWp5wN9SEGYqxpdtjHKzWVkmHEo/1/profile.json"))
+	c.RenewName("muneeb.id", 430000)
+	c.UpdateZonefile("muneeb.id", 440000, FixtureZonefile("muneeb.id", "https://gaia.blockstack.org/hub/17hEAjUUWp5wN9SEGYqxpdtjHKzWVkmHEo/2/profile.json"))
+	c.UpdateZonefile("muneeb.id", 480003, FixtureZonefile("muneeb.id", "https://gaia.blockstack.org/hub/17hEAjUUWp5wN9SEGYqxpdtjHKzWVkmHEo/3/profile.json"))
+
+	c.AddName("judecn.id", "16EMaNw3pkn3v6f2BgnSSs53zAKH4Q8YJg", 373900)
+	c.RenewName("judecn.id", 470000)
+	c.UpdateZonefile("judecn.id", 480003, FixtureZonefile("judecn.id", "https://gaia.blockstack.org/hub/16EMaNw3pkn3v6f2BgnSSs53zAKH4Q8YJg/0/profile.json"))
+
+	c.AddName("ryan.id", "15GAGiT2j2F1EzZrvjk3B8vBCfwVEzQaZx", 374100)
+	c.RenewName("ryan.id", 470000)
+	c.UpdateZonefile("ryan.id", 480003, FixtureZonefile("ryan.id", "https://gaia.blockstack.org/hub/15GAGiT2j2F1EzZrvjk3B8vBCfwVEzQaZx/0/profile.json"))
+
+	// A name that has never set a zonefile
+	c.AddName("nozonefile.id", "1Bv2vJMqBLrm7pRGTsMqDeoKb8bf6fsBPe", 460000)
+
+	// A name that expired without being renewed
+	c.AddName("expired.id", "1Bv2vJMqBLrm7pRGTsMqDeoKb8bf6fsBPe", 380000)
+
+	c.AddName("jude.helloworld", "16EMaNw3pkn3v6f2BgnSSs53zAKH4Q8YJg", 420500)
+	c.UpdateZonefile("jude.helloworld", 420600, FixtureZonefile("jude.helloworld", "https://gaia.blockstack.org/hub/16EMaNw3pkn3v6f2BgnSSs53zAKH4Q8YJg/1/profile.json"))
+}
@@ -0,0 +1,399 @@
+package blockstacktest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+)
+
+// defaultHandlers implements the blockstack-core RPC methods supported by blockstack.Client
+var defaultHandlers = map[string]HandlerFunc{
+	"ping":                            ping,
+	"getinfo":                         getInfo,
+	"get_zonefiles_by_block":          getZonefilesByBlock,
+	"get_name_blockchain_record":      getNameBlockchainRecord,
+	"get_name_history_blocks":         getNameHistoryBlocks,
+	"get_name_at":                     getNameAt,
+	"get_names_owned_by_address":      getNamesOwnedByAddress,
+	"get_name_cost":                   getNameCost,
+	"get_namespace_cost":              getNamespaceCost,
+	"get_num_names":                   getNumNames,
+	"get_all_names":                   getAllNames,
+	"get_all_namespaces":              getAllNamespaces,
+	"get_names_in_namespace":          getNamesInNamespace,
+	"get_num_names_in_namespace":      getNumNamesInNamespace,
+	"get_consensus_at":                getConsensusAt,
+	"get_block_from_consensus":        getBlockFromConsensus,
+	"get_atlas_peers":                 getAtlasPeers,
+	"get_zonefile_inventory":          getZonefileInventory,
+	"get_nameops_hash_at":             getNameOpsHashAt,
+	"get_namespace_blockchain_record": getNamespaceBlockchainRecord,
+	"get_zonefiles":                   getZonefiles,
+	"get_op_history_rows":             getOpHistoryRows,
+	"get_num_op_history_rows":         getNumOpHistoryRows,
+	"get_nameops_affected_at":         getNameOpsAffectedAt,
+	"get_num_nameops_affected_at":     getNumNameOpsAffectedAt,
+	"get_consensus_hashes":            getConsensusHashes,
+}
+
+// result adds the status, lastblock and indexing fields to a result
+func (c *Core) result(fields map[string]interface{}) map[string]interface{} {
+	fields["status"] = true
+	fields["lastblock"] = c.Info.LastBlockProcessed
+	fields["indexing"] = c.Info.Indexing
+	return fields
+}
+
+// names returns all the names on the node in order
+func (c *Core) names() []string {
+	var out []string
+	for name := range c.Records {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// namesInNamespace returns the names in a namespace in order
+func (c *Core) namesInNamespace(ns string) []string {
+	var out []string
+	for _, name := range c.names() {
+		if c.Records[name].Record.NamespaceID == ns {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// historyBlocks returns the blocks in a name's history in order
+func historyBlocks(history map[int][]blockstack.Transaction) []int {
+	var out []int
+	for block := range history {
+		out = append(out, block)
+	}
+	sort.Ints(out)
+	return out
+}
+
+// nameOpsAt returns every name operation at block
+func (c *Core) nameOpsAt(block int) []blockstack.Transaction {
+	out := make([]blockstack.Transaction, 0)
+	for _, name := range c.names() {
+		out = append(out, c.Records[name].Record.History[block]...)
+	}
+	return out
+}
+
+// page returns the offset and count window of a list
+func page(length, offset, count int) (int, int) {
+	if offset > length {
+		offset = length
+	}
+	end := offset + count
+	if end > length || count < 0 {
+		end = length
+	}
+	return offset, end
+}
+
+func ping(c *Core, args []interface{}) (interface{}, error) {
+	return map[string]string{"status": "alive"}, nil
+}
+
+func getInfo(c *Core, args []interface{}) (interface{}, error) {
+	info := c.Info
+	info.Consensus = c.consensusAt(info.LastBlockProcessed)
+	info.ZonefileCount = len(c.Zonefiles)
+	return info, nil
+}
+
+func getZonefilesByBlock(c *Core, args []interface{}) (interface{}, error) {
+	start, end, offset, count := intArg(args, 0), intArg(args, 1), intArg(args, 2), intArg(args, 3)
+	info := make([]blockstack.ZonefileHashResult, 0)
+	for _, name := range c.names() {
+		history := c.Records[name].Record.History
+		for _, block := range historyBlocks(history) {
+			if block < start || block > end {
+				continue
+			}
+			for _, tx := range history[block] {
+				if tx.ValueHash != "" {
+					info = append(info, blockstack.ZonefileHashResult{
+						Name:         name,
+						ZonefileHash: tx.ValueHash,
+						BlockHeight:  block,
+						Txid:         tx.Txid,
+					})
+				}
+			}
+		}
+	}
+	sort.SliceStable(info, func(i, j int) bool { return info[i].BlockHeight < info[j].BlockHeight })
+	lo, hi := page(len(info), offset, count)
+	return c.result(map[string]interface{}{"zonefile_info": info[lo:hi]}), nil
+}
+
+func getNameBlockchainRecord(c *Core, args []interface{}) (interface{}, error) {
+	rec, ok := c.Records[stringArg(args, 0)]
+	if !ok {
+		return nil, errNotFound
+	}
+	rec.Status = true
+	rec.Lastblock = c.Info.LastBlockProcessed
+	rec.Indexing = c.Info.Indexing
+	rec.Record.Expired = rec.Record.ExpireBlock <= c.Info.LastBlockProcessed
+	return rec, nil
+}
+
+func getNameHistoryBlocks(c *Core, args []interface{}) (interface{}, error) {
+	rec, ok := c.Records[stringArg(args, 0)]
+	if !ok {
+		return nil, errNotFound
+	}
+	return c.result(map[string]interface{}{"history_blocks": historyBlocks(rec.Record.History)}), nil
+}
+
+func getNameAt(c *Core, args []interface{}) (interface{}, error) {
+	rec, ok := c.Records[stringArg(args, 0)]
+	if !ok {
+		return nil, errNotFound
+	}
+	// The state of the name at a block is the last operation at or before it
+	at := intArg(args, 1)
+	records := make([]blockstack.Transaction, 0)
+	for _, block := range historyBlocks(rec.Record.History) {
+		if block <= at {
+			txs := rec.Record.History[block]
+			records = []blockstack.Transaction{txs[len(txs)-1]}
+		}
+	}
+	return c.result(map[string]interface{}{"records": records}), nil
+}
+
+func getNamesOwnedByAddress(c *Core, args []interface{}) (interface{}, error) {
+	address := stringArg(args, 0)
+	names := make([]string, 0)
+	for _, name := range c.names() {
+		if c.Records[name].Record.Address == address {
+			names = append(names, name)
+		}
+	}
+	return c.result(map[string]interface{}{"names": names}), nil
+}
+
+func getNameCost(c *Core, args []interface{}) (interface{}, error) {
+	return c.result(map[string]interface{}{"satoshis": c.NameCost}), nil
+}
+
+func getNamespaceCost(c *Core, args []interface{}) (interface{}, error) {
+	return c.result(map[string]interface{}{"satoshis": c.NamespaceCost}), nil
+}
+
+func getNumNames(c *Core, args []interface{}) (interface{}, error) {
+	return c.result(map[string]interface{}{"count": len(c.Records)}), nil
+}
+
+func getAllNames(c *Core, args []interface{}) (interface{}, error) {
+	names := c.names()
+	lo, hi := page(len(names), intArg(args, 0), intArg(args, 1))
+	return c.result(map[string]interface{}{"names": append([]string{}, names[lo:hi]...)}), nil
+}
+
+func getAllNamespaces(c *Core, args []interface{}) (interface{}, error) {
+	namespaces := make([]string, 0)
+	for ns := range c.Namespaces {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return c.result(map[string]interface{}{"namespaces": namespaces}), nil
+}
+
+func getNamesInNamespace(c *Core, args []interface{}) (interface{}, error) {
+	names := c.namesInNamespace(stringArg(args, 0))
+	lo, hi := page(len(names), intArg(args, 1), intArg(args, 2))
+	return c.result(map[string]interface{}{"names": append([]string{}, names[lo:hi]...)}), nil
+}
+
+func getNumNamesInNamespace(c *Core, args []interface{}) (interface{}, error) {
+	return c.result(map[string]interface{}{"count": len(c.namesInNamespace(stringArg(args, 0)))}), nil
+}
+
+func getConsensusAt(c *Core, args []interface{}) (interface{}, error) {
+	return c.result(map[string]interface{}{"consensus": c.consensusAt(intArg(args, 0))}), nil
+}
+
+func getBlockFromConsensus(c *Core, args []interface{}) (interface{}, error) {
+	hash := stringArg(args, 0)
+	for block := blockstack.StartBlock; block <= c.Info.LastBlockProcessed; block++ {
+		if c.consensusAt(block) == hash {
+			return c.result(map[string]interface{}{"block_id": block}), nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func getAtlasPeers(c *Core, args []interface{}) (interface{}, error) {
+	peers := append([]string{}, c.Peers...)
+	return c.result(map[string]interface{}{"peers": peers}), nil
+}
+
+func getZonefileInventory(c *Core, args []interface{}) (interface{}, error) {
+	// Every zonefile the node knows about is present
+	inv := make([]byte, (len(c.Zonefiles)+7)/8)
+	for i := 0; i < len(c.Zonefiles); i++ {
+		inv[i/8] |= 1 << uint(7-i%8)
+	}
+	lo, hi := page(len(inv), intArg(args, 0)/8, (intArg(args, 1)+7)/8)
+	return c.result(map[string]interface{}{"inv": base64.StdEncoding.EncodeToString(inv[lo:hi])}), nil
+}
+
+func getNameOpsHashAt(c *Core, args []interface{}) (interface{}, error) {
+	return c.result(map[string]interface{}{"ops_hash": c.opsHashAt(intArg(args, 0))}), nil
+}
+
+func getNamespaceBlockchainRecord(c *Core, args []interface{}) (interface{}, error) {
+	rec, ok := c.Namespaces[stringArg(args, 0)]
+	if !ok {
+		return nil, errNotFound
+	}
+	rec.Status = true
+	rec.Lastblock = c.Info.LastBlockProcessed
+	rec.Indexing = c.Info.Indexing
+	return rec, nil
+}
+
+func getZonefiles(c *Core, args []interface{}) (interface{}, error) {
+	zonefiles := make(map[string]string)
+	for _, hash := range stringsArg(args, 0) {
+		if zf, ok := c.Zonefiles[hash]; ok {
+			zonefiles[hash] = base64.StdEncoding.EncodeToString([]byte(zf))
+		}
+	}
+	return c.result(map[string]interface{}{"zonefiles": zonefiles}), nil
+}
+
+// historyRow is a row from the history table in blockstack-core
+type historyRow struct {
+	BlockID     int    `json:"block_id"`
+	Op          string `json:"op"`
+	HistoryID   string `json:"history_id"`
+	HistoryData string `json:"history_data"`
+	Vtxindex    int    `json:"vtxindex"`
+	Txid        string `json:"txid"`
+}
+
+// historyRows returns the history table rows for a name or namespace
+func (c *Core) historyRows(historyID string) ([]historyRow, error) {
+	rows := make([]historyRow, 0)
+	if rec, ok := c.Records[historyID]; ok {
+		for _, block := range historyBlocks(rec.Record.History) {
+			for _, tx := range rec.Record.History[block] {
+				data, _ := json.Marshal(tx)
+				rows = append(rows, historyRow{BlockID: block, Op: tx.Op, HistoryID: historyID, HistoryData: string(data), Vtxindex: tx.Vtxindex, Txid: tx.Txid})
+			}
+		}
+		return rows, nil
+	}
+	if rec, ok := c.Namespaces[historyID]; ok {
+		var blocks []int
+		for block := range rec.Record.History {
+			blocks = append(blocks, block)
+		}
+		sort.Ints(blocks)
+		for _, block := range blocks {
+			for _, tx := range rec.Record.History[block] {
+				data, _ := json.Marshal(tx)
+				rows = append(rows, historyRow{BlockID: block, Op: tx.Op, HistoryID: historyID, HistoryData: string(data), Vtxindex: tx.Vtxindex, Txid: tx.Txid})
+			}
+		}
+		return rows, nil
+	}
+	return nil, errNotFound
+}
+
+func getOpHistoryRows(c *Core, args []interface{}) (interface{}, error) {
+	rows, err := c.historyRows(stringArg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+	lo, hi := page(len(rows), intArg(args, 1), intArg(args, 2))
+	return c.result(map[string]interface{}{"history_rows": rows[lo:hi]}), nil
+}
+
+func getNumOpHistoryRows(c *Core, args []interface{}) (interface{}, error) {
+	rows, err := c.historyRows(stringArg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+	return c.result(map[string]interface{}{"count": len(rows)}), nil
+}
+
+func getNameOpsAffectedAt(c *Core, args []interface{}) (interface{}, error) {
+	ops := c.nameOpsAt(intArg(args, 0))
+	lo, hi := page(len(ops), intArg(args, 1), intArg(args, 2))
+	return c.result(map[string]interface{}{"nameops": ops[lo:hi]}), nil
+}
+
+func getNumNameOpsAffectedAt(c *Core, args []interface{}) (interface{}, error) {
+	return c.result(map[string]interface{}{"count": len(c.nameOpsAt(intArg(args, 0)))}), nil
+}
+
+func getConsensusHashes(c *Core, args []interface{}) (interface{}, error) {
+	hashes := make(map[int]string)
+	for _, block := range intsArg(args, 0) {
+		hashes[block] = c.consensusAt(block)
+	}
+	return c.result(map[string]interface{}{"consensus_hashes": hashes}), nil
+}
+
+// intArg returns the int argument at i or 0
+func intArg(args []interface{}, i int) int {
+	if i < len(args) {
+		if v, ok := args[i].(int); ok {
+			return v
+		}
+	}
+	return 0
+}
+
+// stringArg returns the string argument at i or ""
+func stringArg(args []interface{}, i int) string {
+	if i < len(args) {
+		if v, ok := args[i].(string); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// stringsArg returns the array argument at i as []string
+func stringsArg(args []interface{}, i int) []string {
+	var out []string
+	if i < len(args) {
+		if arr, ok := args[i].([]interface{}); ok {
+			for _, v := range arr {
+				out = append(out, fmt.Sprint(v))
+			}
+		}
+	}
+	return out
+}
+
+// intsArg returns the array argument at i as []int
+func intsArg(args []interface{}, i int) []int {
+	var out []int
+	if i < len(args) {
+		if arr, ok := args[i].([]interface{}); ok {
+			for _, v := range arr {
+				if n, ok := v.(int); ok {
+					out = append(out, n)
+				}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,90 @@
+package blockstacktest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// methodCall is the body of an XML-RPC request
+type methodCall struct {
+	MethodName string  `xml:"methodName"`
+	Params     []value `xml:"params>param>value"`
+}
+
+// value is an XML-RPC value. Only the types blockstack.Client sends are handled
+type value struct {
+	Int     *string `xml:"int"`
+	I4      *string `xml:"i4"`
+	Boolean *string `xml:"boolean"`
+	String  *string `xml:"string"`
+	Array   *struct {
+		Values []value `xml:"data>value"`
+	} `xml:"array"`
+	Chardata string `xml:",chardata"`
+}
+
+// decode converts an XML-RPC value into an int, bool, string or []interface{}
+func (v value) decode() (interface{}, error) {
+	switch {
+	case v.Int != nil:
+		return strconv.Atoi(strings.TrimSpace(*v.Int))
+	case v.I4 != nil:
+		return strconv.Atoi(strings.TrimSpace(*v.I4))
+	case v.Boolean != nil:
+		return strings.TrimSpace(*v.Boolean) == "1", nil
+	case v.String != nil:
+		return *v.String, nil
+	case v.Array != nil:
+		out := make([]interface{}, 0, len(v.Array.Values))
+		for _, av := range v.Array.Values {
+			d, err := av.decode()
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, d)
+		}
+		return out, nil
+	}
+	// A value with no type element is a string
+	return v.Chardata, nil
+}
+
+// parseMethodCall reads the method name and arguments from an XML-RPC request body
+func parseMethodCall(body []byte) (string, []interface{}, error) {
+	var mc methodCall
+	if err := xml.Unmarshal(body, &mc); err != nil {
+		return "", nil, err
+	}
+	var args []interface{}
+	for _, p := range mc.Params {
+		arg, err := p.decode()
+		if err != nil {
+			return "", nil, fmt.Errorf("bad param for %s: %v", mc.MethodName, err)
+		}
+		args = append(args, arg)
+	}
+	return mc.MethodName, args, nil
+}
+
+// encodeResponse wraps a blockstack-core JSON result in an XML-RPC response
+func encodeResponse(result string) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><methodResponse><params><param><value><string>`)
+	xml.EscapeText(&b, []byte(result))
+	b.WriteString(`</string></value></param></params></methodResponse>`)
+	return b.Bytes()
+}
+
+// encodeFault returns an XML-RPC fault response
+func encodeFault(code int, msg string) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><methodResponse><fault><value><struct>`)
+	fmt.Fprintf(&b, `<member><name>faultCode</name><value><int>%d</int></value></member>`, code)
+	b.WriteString(`<member><name>faultString</name><value><string>`)
+	xml.EscapeText(&b, []byte(msg))
+	b.WriteString(`</string></value></member></struct></value></fault></methodResponse>`)
+	return b.Bytes()
+}
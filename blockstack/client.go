@@ -1,19 +1,22 @@
 package blockstack
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"net/url"
-
-	"github.com/kolo/xmlrpc"
+	"strings"
+	"time"
 )
 
 // Client is the exportable object that the RPC methods are defined on
 type Client struct {
-	node    *xmlrpc.Client
+	node    Caller
 	config  ServerConfig
 	getInfo GetInfoResult
+	hooks   []Hooks
 }
 
 // Clients is a collection of clients
@@ -67,14 +70,14 @@ func (c Clients) register() ([]*Client, []Error) {
 		res, err := client.GetInfo()
 		// If there is no result to check against, and no error on call
 		// And not indexing, the result is the right one.
-		if err == nil && !res.Indexing {
+		if _, indexing := err.(IndexingError); err == nil {
 			client.getInfo = res
 			getInfo = append(getInfo, client)
 			// If client is indexing return the error
-		} else if err == nil && res.Indexing {
+		} else if indexing {
 			getInfoErrs = append(getInfoErrs, ClientRegistrationError{URL: client.config.String(), Err: "Client still indexing"})
 			// If the error is not nil, return the error
-		} else if err != nil {
+		} else {
 			getInfoErrs = append(getInfoErrs, ClientRegistrationError{URL: client.config.String(), Err: err.Error()})
 		}
 	}
@@ -120,12 +123,14 @@ func ValidClients(urls []string) ([]*Client, []Error) {
 
 // NewClient creates a new instance of the blockstack-core rpc client
 func NewClient(conf ServerConfig) *Client {
-	client, err := xmlrpc.NewClient(conf.String(), nil)
-	if err != nil {
-		log.Fatal(err)
-	}
+	return NewClientWithCaller(conf, newHTTPNode(conf))
+}
+
+// NewClientWithCaller creates a client that makes its RPC calls through caller
+// conf is still used to identify the node in errors and logs
+func NewClientWithCaller(conf ServerConfig, caller Caller) *Client {
 	return &Client{
-		node:   client,
+		node:   caller,
 		config: conf,
 	}
 }
@@ -135,6 +140,13 @@ type ServerConfig struct {
 	Address string `json:"address" yaml:"address"`
 	Port    string `json:"port" yaml:"port"`
 	Scheme  string `json:"scheme" yaml:"scheme"`
+
+	// Timeout bounds every RPC call made to the node, 0 means no timeout.
+	// Use the *Context methods on Client for per-call deadlines
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// Transport is used to make the HTTP requests, http.DefaultTransport if nil
+	Transport http.RoundTripper `json:"-" yaml:"-"`
 }
 
 func (s ServerConfig) String() string {
@@ -249,6 +261,140 @@ func (err JSONUnmarshalError) PrettyJSON() string {
 	return string(byt)
 }
 
+// IndexingError is returned when the blockstack-core node reports that it is
+// still indexing and its results may be stale. The decoded result is returned with it
+type IndexingError struct {
+	RPC       string `json:"rpc_method"`
+	Lastblock int    `json:"lastblock"`
+}
+
+// Error satisfies the error interface
+func (err IndexingError) Error() string {
+	return "blockstack-core node is still indexing"
+}
+
+// JSON allows for easy Marshal
+func (err IndexingError) JSON() string {
+	byt, e := json.Marshal(err)
+	if e != nil {
+		log.Fatal(e)
+	}
+	return string(byt)
+}
+
+// PrettyJSON allows for easy Marshal
+func (err IndexingError) PrettyJSON() string {
+	byt, e := json.MarshalIndent(err, "", "    ")
+	if e != nil {
+		log.Fatal(e)
+	}
+	return string(byt)
+}
+
+// StatusError is returned when blockstack-core answers a call with status false.
+// The decoded result is returned with it
+type StatusError struct {
+	RPC       string `json:"rpc_method"`
+	Lastblock int    `json:"lastblock"`
+}
+
+// Error satisfies the error interface
+func (err StatusError) Error() string {
+	return "blockstack-core returned status false"
+}
+
+// JSON allows for easy Marshal
+func (err StatusError) JSON() string {
+	byt, e := json.Marshal(err)
+	if e != nil {
+		log.Fatal(e)
+	}
+	return string(byt)
+}
+
+// PrettyJSON allows for easy Marshal
+func (err StatusError) PrettyJSON() string {
+	byt, e := json.MarshalIndent(err, "", "    ")
+	if e != nil {
+		log.Fatal(e)
+	}
+	return string(byt)
+}
+
+// QuorumError is returned when too few blockstack-core nodes agree on a result
+type QuorumError struct {
+	RPC       string `json:"rpc_method"`
+	Required  int    `json:"required"`
+	Agreed    int    `json:"agreed"`
+	Nodes     int    `json:"nodes"`
+	Lastblock int    `json:"lastblock"`
+}
+
+// Error satisfies the error interface
+func (err QuorumError) Error() string {
+	return fmt.Sprintf("%d of %d nodes agreed on %s at block %d, %d required", err.Agreed, err.Nodes, err.RPC, err.Lastblock, err.Required)
+}
+
+// JSON allows for easy Marshal
+func (err QuorumError) JSON() string {
+	byt, e := json.Marshal(err)
+	if e != nil {
+		log.Fatal(e)
+	}
+	return string(byt)
+}
+
+// PrettyJSON allows for easy Marshal
+func (err QuorumError) PrettyJSON() string {
+	byt, e := json.MarshalIndent(err, "", "    ")
+	if e != nil {
+		log.Fatal(e)
+	}
+	return string(byt)
+}
+
+// ZonefileMismatch is a zonefile that does not hash to the value hash it was returned under
+type ZonefileMismatch struct {
+	Hash   string `json:"hash"`
+	Actual string `json:"actual,omitempty"`
+	Err    string `json:"error"`
+}
+
+// ZonefileHashError is returned when a blockstack-core node serves zonefiles
+// that do not hash to the value hash they were requested by. Those zonefiles
+// are dropped and the verified ones are returned with it
+type ZonefileHashError struct {
+	RPC        string             `json:"rpc_method"`
+	Mismatched []ZonefileMismatch `json:"mismatched"`
+}
+
+// Error satisfies the error interface
+func (err ZonefileHashError) Error() string {
+	hashes := make([]string, 0, len(err.Mismatched))
+	for _, m := range err.Mismatched {
+		hashes = append(hashes, m.Hash)
+	}
+	return fmt.Sprintf("%s returned %d zonefiles that do not match their hash: %s", err.RPC, len(hashes), strings.Join(hashes, ", "))
+}
+
+// JSON allows for easy Marshal
+func (err ZonefileHashError) JSON() string {
+	byt, e := json.Marshal(err)
+	if e != nil {
+		log.Fatal(e)
+	}
+	return string(byt)
+}
+
+// PrettyJSON allows for easy Marshal
+func (err ZonefileHashError) PrettyJSON() string {
+	byt, e := json.MarshalIndent(err, "", "    ")
+	if e != nil {
+		log.Fatal(e)
+	}
+	return string(byt)
+}
+
 // ClientRegistrationError represents an error resulting from a failed RPC call
 type ClientRegistrationError struct {
 	URL string `json:"url"`
@@ -281,7 +427,7 @@ func (err ClientRegistrationError) PrettyJSON() string {
 // TestMethod calls an RPC method with the given args
 func (bsk *Client) TestMethod(methodName string, args []interface{}) string {
 	var result string
-	err := bsk.node.Call(methodName, args, &result)
+	err := bsk.call(context.Background(), methodName, args, &result)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,242 @@
+package blockstack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+)
+
+const (
+	// consensusHashesPageSize is the most blocks blockstack-core returns consensus hashes for per call
+	consensusHashesPageSize = 32
+	// nameOpsPageSize is the most name operations blockstack-core returns per call
+	nameOpsPageSize = 10
+)
+
+// ForkReport describes where a set of blockstack-core nodes stop agreeing on the consensus hash
+type ForkReport struct {
+	// Forked is false when the nodes agree at every block up to End
+	Forked bool `json:"forked"`
+	Start  int  `json:"start"`
+	End    int  `json:"end"`
+	// Block is the first block the nodes disagree at, LastAgreed the block before it
+	Block      int `json:"block,omitempty"`
+	LastAgreed int `json:"last_agreed,omitempty"`
+	// Nodes are the state of each node at Block, or at End when they did not fork
+	Nodes []NodeFork `json:"nodes"`
+}
+
+// NodeFork is the state of one node at the block a ForkReport is about
+type NodeFork struct {
+	Node      string `json:"node"`
+	Consensus string `json:"consensus"`
+	OpsHash   string `json:"ops_hash,omitempty"`
+	// Majority is set for the nodes in the largest group that agree on the consensus hash
+	Majority bool `json:"majority"`
+	// Missing are the name operations the majority has at the block and this node does not,
+	// Extra the ones this node has and the majority does not
+	Missing []Transaction `json:"missing,omitempty"`
+	Extra   []Transaction `json:"extra,omitempty"`
+}
+
+// JSON returns the JSON representation of ForkReport
+func (r ForkReport) JSON() string {
+	byt, err := json.Marshal(r)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return string(byt)
+}
+
+// PrettyJSON returns the Pretty Printed JSON representation of ForkReport
+func (r ForkReport) PrettyJSON() string {
+	byt, err := json.MarshalIndent(r, "", "    ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return string(byt)
+}
+
+// FindFork binary searches the blocks from start to end for the first one at
+// which clients report different consensus hashes. Consensus hashes commit to
+// the one before them, so once nodes disagree they disagree at every later block.
+// At the first such block the name ops hash and the name operations of each
+// node are compared against those of the majority. end should be at most the
+// last block processed by every node
+func FindFork(ctx context.Context, clients []*Client, start, end int) (ForkReport, error) {
+	report := ForkReport{Start: start, End: end}
+	if len(clients) < 2 {
+		return report, fmt.Errorf("at least 2 nodes are needed to look for a fork, got %d", len(clients))
+	}
+	if start > end {
+		return report, fmt.Errorf("start block %d is after end block %d", start, end)
+	}
+
+	edges, err := consensusAcross(ctx, clients, []int{start, end})
+	if err != nil {
+		return report, err
+	}
+	if agree(edges, end) {
+		report.Nodes = nodeForks(clients, edges, end)
+		return report, nil
+	}
+	report.Forked = true
+	if !agree(edges, start) {
+		report.Block = start
+		return forkDetail(ctx, clients, report)
+	}
+
+	// The nodes agree at lo and disagree at hi
+	lo, hi := start, end
+	for hi-lo > 1 {
+		probes := probeBlocks(lo, hi)
+		hashes, err := consensusAcross(ctx, clients, probes)
+		if err != nil {
+			return report, err
+		}
+		next := hi
+		for _, block := range probes {
+			if !agree(hashes, block) {
+				next = block
+				break
+			}
+			lo = block
+		}
+		hi = next
+	}
+	report.Block, report.LastAgreed = hi, lo
+	return forkDetail(ctx, clients, report)
+}
+
+// probeBlocks returns up to consensusHashesPageSize blocks spread evenly between lo and hi, exclusive
+func probeBlocks(lo, hi int) []int {
+	n := hi - lo - 1
+	if n > consensusHashesPageSize {
+		n = consensusHashesPageSize
+	}
+	out := make([]int, 0, n)
+	for i := 1; i <= n; i++ {
+		block := lo + i*(hi-lo)/(n+1)
+		if len(out) == 0 || block != out[len(out)-1] {
+			out = append(out, block)
+		}
+	}
+	return out
+}
+
+// consensusAcross returns the consensus hashes at blocks from each client, in the order of clients
+func consensusAcross(ctx context.Context, clients []*Client, blocks []int) ([]map[int]string, Error) {
+	out := make([]map[int]string, len(clients))
+	for i, c := range clients {
+		res, err := c.GetConsensusHashesContext(ctx, blocks)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = res.ConsensusHashes
+	}
+	return out, nil
+}
+
+// agree reports whether every node has the same consensus hash at block
+func agree(hashes []map[int]string, block int) bool {
+	for _, h := range hashes[1:] {
+		if h[block] != hashes[0][block] {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeForks returns the consensus hash of each node at block, marking the majority
+func nodeForks(clients []*Client, hashes []map[int]string, block int) []NodeFork {
+	out := make([]NodeFork, len(clients))
+	for i, c := range clients {
+		out[i] = NodeFork{Node: c.config.String(), Consensus: hashes[i][block]}
+	}
+	majority := majorityConsensus(out)
+	for i := range out {
+		out[i].Majority = out[i].Consensus == majority
+	}
+	return out
+}
+
+// majorityConsensus returns the consensus hash the most nodes agree on, the
+// one of the earliest node on a tie
+func majorityConsensus(nodes []NodeFork) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, n := range nodes {
+		counts[n.Consensus]++
+		if best == "" || counts[n.Consensus] > counts[best] {
+			best = n.Consensus
+		}
+	}
+	return best
+}
+
+// forkDetail fills in the consensus hash, name ops hash and name operations of each node at report.Block
+func forkDetail(ctx context.Context, clients []*Client, report ForkReport) (ForkReport, error) {
+	hashes, err := consensusAcross(ctx, clients, []int{report.Block})
+	if err != nil {
+		return report, err
+	}
+	report.Nodes = nodeForks(clients, hashes, report.Block)
+	ops := make([][]Transaction, len(clients))
+	var majorityOps []Transaction
+	for i, c := range clients {
+		res, err := c.GetNameOpsHashAtContext(ctx, report.Block)
+		if err != nil {
+			return report, err
+		}
+		report.Nodes[i].OpsHash = res.OpsHash
+		nodeOps, err := nameOpsAt(ctx, c, report.Block)
+		if err != nil {
+			return report, err
+		}
+		ops[i] = nodeOps
+		if report.Nodes[i].Majority && majorityOps == nil {
+			majorityOps = ops[i]
+		}
+	}
+	for i := range report.Nodes {
+		if !report.Nodes[i].Majority {
+			report.Nodes[i].Missing = diffOps(majorityOps, ops[i])
+			report.Nodes[i].Extra = diffOps(ops[i], majorityOps)
+		}
+	}
+	return report, nil
+}
+
+// nameOpsAt pages through every name operation c has at block
+func nameOpsAt(ctx context.Context, c *Client, block int) ([]Transaction, Error) {
+	out := make([]Transaction, 0)
+	for offset := 0; ; offset += nameOpsPageSize {
+		res, err := c.GetNameOpsAffectedAtContext(ctx, block, offset, nameOpsPageSize)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, res.Nameops...)
+		if len(res.Nameops) < nameOpsPageSize {
+			return out, nil
+		}
+	}
+}
+
+// diffOps returns the operations in a that are not in b, matched by txid, name and op
+func diffOps(a, b []Transaction) []Transaction {
+	key := func(t Transaction) string { return t.Txid + "/" + t.Name + "/" + t.Opcode }
+	seen := make(map[string]bool, len(b))
+	for _, t := range b {
+		seen[key(t)] = true
+	}
+	var out []Transaction
+	for _, t := range a {
+		if !seen[key(t)] {
+			out = append(out, t)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Vtxindex < out[j].Vtxindex })
+	return out
+}
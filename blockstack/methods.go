@@ -1,778 +1,326 @@
 package blockstack
 
 import (
-	"encoding/json"
+	"context"
 )
 
 // Ping calls the ping RPC method for blockstack server
 func (bsk *Client) Ping() (PingResult, Error) {
-	rpcCall := "ping"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, nil, &callResult)
-	if err != nil {
-		return PingResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return PingResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return PingResult{}, rpcError
-	}
+	return bsk.PingContext(context.Background())
+}
 
+// PingContext calls the ping RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) PingContext(ctx context.Context) (PingResult, Error) {
 	var out PingResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return PingResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "ping", nil, &out)
+	return out, err
 }
 
 // GetInfo calls the getinfo RPC method for blockstack server
 func (bsk *Client) GetInfo() (GetInfoResult, Error) {
-	rpcCall := "getinfo"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, nil, &callResult)
-	if err != nil {
-		return GetInfoResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetInfoResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetInfoResult{}, rpcError
-	}
+	return bsk.GetInfoContext(context.Background())
+}
 
+// GetInfoContext calls the getinfo RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetInfoContext(ctx context.Context) (GetInfoResult, Error) {
 	var out GetInfoResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetInfoResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "getinfo", nil, &out)
+	return out, err
 }
 
 // GetZonefilesByBlock calls the get_zonefiles_by_block RPC method for blockstack server
 func (bsk *Client) GetZonefilesByBlock(startBlock, endBlock, offset, count int) (GetZonefilesByBlockResult, Error) {
-	rpcCall := "get_zonefiles_by_block"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{startBlock, endBlock, offset, count}, &callResult)
-	if err != nil {
-		return GetZonefilesByBlockResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetZonefilesByBlockResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetZonefilesByBlockResult{}, rpcError
-	}
+	return bsk.GetZonefilesByBlockContext(context.Background(), startBlock, endBlock, offset, count)
+}
 
+// GetZonefilesByBlockContext calls the get_zonefiles_by_block RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetZonefilesByBlockContext(ctx context.Context, startBlock, endBlock, offset, count int) (GetZonefilesByBlockResult, Error) {
 	var out GetZonefilesByBlockResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetZonefilesByBlockResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_zonefiles_by_block", []interface{}{startBlock, endBlock, offset, count}, &out)
+	return out, err
 }
 
 // GetNameBlockchainRecord calls the get_name_blockchain_record RPC method for blockstack server
 func (bsk *Client) GetNameBlockchainRecord(name string) (GetNameBlockchainRecordResult, Error) {
-	rpcCall := "get_name_blockchain_record"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{name}, &callResult)
-	if err != nil {
-		return GetNameBlockchainRecordResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetNameBlockchainRecordResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetNameBlockchainRecordResult{}, rpcError
-	}
+	return bsk.GetNameBlockchainRecordContext(context.Background(), name)
+}
 
+// GetNameBlockchainRecordContext calls the get_name_blockchain_record RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetNameBlockchainRecordContext(ctx context.Context, name string) (GetNameBlockchainRecordResult, Error) {
 	var out GetNameBlockchainRecordResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetNameBlockchainRecordResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_name_blockchain_record", []interface{}{name}, &out)
+	return out, err
 }
 
 // GetNameHistoryBlocks calls the get_name_history_blocks RPC method for blockstack server
 func (bsk *Client) GetNameHistoryBlocks(name string) (GetNameHistoryBlocksResult, Error) {
-	rpcCall := "get_name_history_blocks"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{name}, &callResult)
-	if err != nil {
-		return GetNameHistoryBlocksResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetNameHistoryBlocksResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetNameHistoryBlocksResult{}, rpcError
-	}
+	return bsk.GetNameHistoryBlocksContext(context.Background(), name)
+}
 
+// GetNameHistoryBlocksContext calls the get_name_history_blocks RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetNameHistoryBlocksContext(ctx context.Context, name string) (GetNameHistoryBlocksResult, Error) {
 	var out GetNameHistoryBlocksResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetNameHistoryBlocksResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_name_history_blocks", []interface{}{name}, &out)
+	return out, err
 }
 
 // GetNameAt calls the get_name_at RPC method for blockstack server
 func (bsk *Client) GetNameAt(name string, blockHeight int) (GetNameAtResult, Error) {
-	rpcCall := "get_name_at"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{name, blockHeight}, &callResult)
-	if err != nil {
-		return GetNameAtResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetNameAtResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetNameAtResult{}, rpcError
-	}
+	return bsk.GetNameAtContext(context.Background(), name, blockHeight)
+}
 
+// GetNameAtContext calls the get_name_at RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetNameAtContext(ctx context.Context, name string, blockHeight int) (GetNameAtResult, Error) {
 	var out GetNameAtResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetNameAtResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_name_at", []interface{}{name, blockHeight}, &out)
+	return out, err
 }
 
 // GetNamesOwnedByAddress calls the get_names_owned_by_address RPC method for blockstack server
 func (bsk *Client) GetNamesOwnedByAddress(address string) (GetNamesOwnedByAddressResult, Error) {
-	rpcCall := "get_names_owned_by_address"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{address}, &callResult)
-	if err != nil {
-		return GetNamesOwnedByAddressResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetNamesOwnedByAddressResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetNamesOwnedByAddressResult{}, rpcError
-	}
+	return bsk.GetNamesOwnedByAddressContext(context.Background(), address)
+}
 
+// GetNamesOwnedByAddressContext calls the get_names_owned_by_address RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetNamesOwnedByAddressContext(ctx context.Context, address string) (GetNamesOwnedByAddressResult, Error) {
 	var out GetNamesOwnedByAddressResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetNamesOwnedByAddressResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_names_owned_by_address", []interface{}{address}, &out)
+	return out, err
 }
 
 // GetNameCost calls the get_name_cost RPC method for blockstack server
 func (bsk *Client) GetNameCost(name string) (GetNameCostResult, Error) {
-	rpcCall := "get_name_cost"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{name}, &callResult)
-	if err != nil {
-		return GetNameCostResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetNameCostResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetNameCostResult{}, rpcError
-	}
+	return bsk.GetNameCostContext(context.Background(), name)
+}
 
+// GetNameCostContext calls the get_name_cost RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetNameCostContext(ctx context.Context, name string) (GetNameCostResult, Error) {
 	var out GetNameCostResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetNameCostResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_name_cost", []interface{}{name}, &out)
+	return out, err
 }
 
 // GetNamespaceCost calls the get_namespace_cost RPC method for blockstack server
 func (bsk *Client) GetNamespaceCost(namespace string) (GetNamespaceCostResult, Error) {
-	rpcCall := "get_namespace_cost"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{namespace}, &callResult)
-	if err != nil {
-		return GetNamespaceCostResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetNamespaceCostResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetNamespaceCostResult{}, rpcError
-	}
+	return bsk.GetNamespaceCostContext(context.Background(), namespace)
+}
 
+// GetNamespaceCostContext calls the get_namespace_cost RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetNamespaceCostContext(ctx context.Context, namespace string) (GetNamespaceCostResult, Error) {
 	var out GetNamespaceCostResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetNamespaceCostResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_namespace_cost", []interface{}{namespace}, &out)
+	return out, err
 }
 
 // GetNumNames calls the get_num_names RPC method for blockstack server
 func (bsk *Client) GetNumNames() (CountResult, Error) {
-	rpcCall := "get_num_names"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{}, &callResult)
-	if err != nil {
-		return CountResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return CountResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return CountResult{}, rpcError
-	}
+	return bsk.GetNumNamesContext(context.Background())
+}
 
+// GetNumNamesContext calls the get_num_names RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetNumNamesContext(ctx context.Context) (CountResult, Error) {
 	var out CountResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return CountResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_num_names", []interface{}{}, &out)
+	return out, err
 }
 
 // GetAllNames calls the get_all_names RPC method for blockstack server
 func (bsk *Client) GetAllNames(offset, count int) (GetAllNamesResult, Error) {
-	rpcCall := "get_all_names"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{offset, count}, &callResult)
-	if err != nil {
-		return GetAllNamesResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetAllNamesResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetAllNamesResult{}, rpcError
-	}
+	return bsk.GetAllNamesContext(context.Background(), offset, count)
+}
 
+// GetAllNamesContext calls the get_all_names RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetAllNamesContext(ctx context.Context, offset, count int) (GetAllNamesResult, Error) {
 	var out GetAllNamesResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetAllNamesResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_all_names", []interface{}{offset, count}, &out)
+	return out, err
 }
 
 // GetAllNamespaces calls the get_all_namespaces RPC method for blockstack server
 func (bsk *Client) GetAllNamespaces() (GetAllNamespacesResult, Error) {
-	rpcCall := "get_all_namespaces"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{}, &callResult)
-	if err != nil {
-		return GetAllNamespacesResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetAllNamespacesResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetAllNamespacesResult{}, rpcError
-	}
+	return bsk.GetAllNamespacesContext(context.Background())
+}
 
+// GetAllNamespacesContext calls the get_all_namespaces RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetAllNamespacesContext(ctx context.Context) (GetAllNamespacesResult, Error) {
 	var out GetAllNamespacesResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetAllNamespacesResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_all_namespaces", []interface{}{}, &out)
+	return out, err
 }
 
 // GetNamesInNamespace calls the get_names_in_namespace RPC method for blockstack server
 func (bsk *Client) GetNamesInNamespace(ns string, offset int, count int) (GetNamesInNamespaceResult, Error) {
-	rpcCall := "get_names_in_namespace"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{ns, offset, count}, &callResult)
-	if err != nil {
-		return GetNamesInNamespaceResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetNamesInNamespaceResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetNamesInNamespaceResult{}, rpcError
-	}
+	return bsk.GetNamesInNamespaceContext(context.Background(), ns, offset, count)
+}
 
+// GetNamesInNamespaceContext calls the get_names_in_namespace RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetNamesInNamespaceContext(ctx context.Context, ns string, offset int, count int) (GetNamesInNamespaceResult, Error) {
 	var out GetNamesInNamespaceResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetNamesInNamespaceResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_names_in_namespace", []interface{}{ns, offset, count}, &out)
+	return out, err
 }
 
 // GetNumNamesInNamespace calls the get_num_names_in_namespace RPC method for blockstack server
 func (bsk *Client) GetNumNamesInNamespace(namespace string) (CountResult, Error) {
-	rpcCall := "get_num_names_in_namespace"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{namespace}, &callResult)
-	if err != nil {
-		return CountResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return CountResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return CountResult{}, rpcError
-	}
+	return bsk.GetNumNamesInNamespaceContext(context.Background(), namespace)
+}
 
+// GetNumNamesInNamespaceContext calls the get_num_names_in_namespace RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetNumNamesInNamespaceContext(ctx context.Context, namespace string) (CountResult, Error) {
 	var out CountResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return CountResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_num_names_in_namespace", []interface{}{namespace}, &out)
+	return out, err
 }
 
 // GetConsensusAt calls the get_consensus_at RPC method for blockstack server
 func (bsk *Client) GetConsensusAt(blockHeight int) (GetConsensusAtResult, Error) {
-	rpcCall := "get_consensus_at"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{blockHeight}, &callResult)
-	if err != nil {
-		return GetConsensusAtResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetConsensusAtResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetConsensusAtResult{}, rpcError
-	}
+	return bsk.GetConsensusAtContext(context.Background(), blockHeight)
+}
 
+// GetConsensusAtContext calls the get_consensus_at RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetConsensusAtContext(ctx context.Context, blockHeight int) (GetConsensusAtResult, Error) {
 	var out GetConsensusAtResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetConsensusAtResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_consensus_at", []interface{}{blockHeight}, &out)
+	return out, err
 }
 
 // GetBlockFromConsensus calls the get_block_from_consensus RPC method for blockstack server
 func (bsk *Client) GetBlockFromConsensus(consensusHash string) (GetBlockFromConsensusResult, Error) {
-	rpcCall := "get_block_from_consensus"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{consensusHash}, &callResult)
-	if err != nil {
-		return GetBlockFromConsensusResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetBlockFromConsensusResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetBlockFromConsensusResult{}, rpcError
-	}
+	return bsk.GetBlockFromConsensusContext(context.Background(), consensusHash)
+}
 
+// GetBlockFromConsensusContext calls the get_block_from_consensus RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetBlockFromConsensusContext(ctx context.Context, consensusHash string) (GetBlockFromConsensusResult, Error) {
 	var out GetBlockFromConsensusResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetBlockFromConsensusResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_block_from_consensus", []interface{}{consensusHash}, &out)
+	return out, err
 }
 
 // GetAtlasPeers calls the get_atlas_peers RPC method for blockstack server
 func (bsk *Client) GetAtlasPeers() (GetAtlasPeersResult, Error) {
-	rpcCall := "get_atlas_peers"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{}, &callResult)
-	if err != nil {
-		return GetAtlasPeersResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetAtlasPeersResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetAtlasPeersResult{}, rpcError
-	}
+	return bsk.GetAtlasPeersContext(context.Background())
+}
 
+// GetAtlasPeersContext calls the get_atlas_peers RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetAtlasPeersContext(ctx context.Context) (GetAtlasPeersResult, Error) {
 	var out GetAtlasPeersResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetAtlasPeersResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_atlas_peers", []interface{}{}, &out)
+	return out, err
 }
 
 // GetZonefileInventory calls the get_zonefile_inventory RPC method for blockstack server
 func (bsk *Client) GetZonefileInventory(offset, length int) (GetZonefileInventoryResult, Error) {
-	rpcCall := "get_zonefile_inventory"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{offset, length}, &callResult)
-	if err != nil {
-		return GetZonefileInventoryResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetZonefileInventoryResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetZonefileInventoryResult{}, rpcError
-	}
+	return bsk.GetZonefileInventoryContext(context.Background(), offset, length)
+}
 
+// GetZonefileInventoryContext calls the get_zonefile_inventory RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetZonefileInventoryContext(ctx context.Context, offset, length int) (GetZonefileInventoryResult, Error) {
 	var out GetZonefileInventoryResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetZonefileInventoryResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_zonefile_inventory", []interface{}{offset, length}, &out)
+	return out, err
 }
 
 // GetNameOpsHashAt calls the get_nameops_hash_at RPC method for blockstack server
 func (bsk *Client) GetNameOpsHashAt(blockHeight int) (GetNameOpsHashAtResult, Error) {
-	rpcCall := "get_nameops_hash_at"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{blockHeight}, &callResult)
-	if err != nil {
-		return GetNameOpsHashAtResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetNameOpsHashAtResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetNameOpsHashAtResult{}, rpcError
-	}
+	return bsk.GetNameOpsHashAtContext(context.Background(), blockHeight)
+}
 
+// GetNameOpsHashAtContext calls the get_nameops_hash_at RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetNameOpsHashAtContext(ctx context.Context, blockHeight int) (GetNameOpsHashAtResult, Error) {
 	var out GetNameOpsHashAtResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetNameOpsHashAtResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_nameops_hash_at", []interface{}{blockHeight}, &out)
+	return out, err
 }
 
 // GetNamespaceBlockchainRecord calls the get_namespace_blockchain_record RPC method for blockstack server
 func (bsk *Client) GetNamespaceBlockchainRecord(namespace string) (GetNamespaceBlockchainRecordResult, Error) {
-	rpcCall := "get_namespace_blockchain_record"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{namespace}, &callResult)
-	if err != nil {
-		return GetNamespaceBlockchainRecordResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetNamespaceBlockchainRecordResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetNamespaceBlockchainRecordResult{}, rpcError
-	}
+	return bsk.GetNamespaceBlockchainRecordContext(context.Background(), namespace)
+}
 
+// GetNamespaceBlockchainRecordContext calls the get_namespace_blockchain_record RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetNamespaceBlockchainRecordContext(ctx context.Context, namespace string) (GetNamespaceBlockchainRecordResult, Error) {
 	var out GetNamespaceBlockchainRecordResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetNamespaceBlockchainRecordResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_namespace_blockchain_record", []interface{}{namespace}, &out)
+	return out, err
 }
 
 // GetZonefiles calls the get_zonefiles RPC method for blockstack server
 func (bsk *Client) GetZonefiles(zonefiles []string) (GetZonefilesResult, Error) {
-	rpcCall := "get_zonefiles"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{zonefiles}, &callResult)
-	if err != nil {
-		return GetZonefilesResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetZonefilesResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetZonefilesResult{}, rpcError
-	}
+	return bsk.GetZonefilesContext(context.Background(), zonefiles)
+}
 
+// GetZonefilesContext calls the get_zonefiles RPC method for blockstack server and aborts the call when ctx is done.
+// Zonefiles that do not hash to the value hash they were requested by are dropped and reported in a ZonefileHashError
+func (bsk *Client) GetZonefilesContext(ctx context.Context, zonefiles []string) (GetZonefilesResult, Error) {
 	var out GetZonefilesResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetZonefilesResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_zonefiles", []interface{}{zonefiles}, &out)
+	if _, bad := out.Verify(); len(bad) > 0 {
+		for _, m := range bad {
+			delete(out.Zonefiles, m.Hash)
+		}
+		if err == nil {
+			err = ZonefileHashError{RPC: "get_zonefiles", Mismatched: bad}
+		}
+	}
+	return out, err
 }
 
 // GetOpHistoryRows calls the get_op_history_rows RPC method for blockstack server
 func (bsk *Client) GetOpHistoryRows(historyID string, offset int, count int) (GetOpHistoryRowsResult, Error) {
-	rpcCall := "get_op_history_rows"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{historyID, offset, count}, &callResult)
-	if err != nil {
-		return GetOpHistoryRowsResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetOpHistoryRowsResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetOpHistoryRowsResult{}, rpcError
-	}
+	return bsk.GetOpHistoryRowsContext(context.Background(), historyID, offset, count)
+}
 
+// GetOpHistoryRowsContext calls the get_op_history_rows RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetOpHistoryRowsContext(ctx context.Context, historyID string, offset int, count int) (GetOpHistoryRowsResult, Error) {
 	var out GetOpHistoryRowsResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetOpHistoryRowsResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_op_history_rows", []interface{}{historyID, offset, count}, &out)
+	return out, err
 }
 
 // GetNameOpsAffectedAt calls the get_nameops_affected_at RPC method for blockstack server
 func (bsk *Client) GetNameOpsAffectedAt(blockID, offset, count int) (GetNameOpsAffectedAtResult, Error) {
-	rpcCall := "get_nameops_affected_at"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{blockID, offset, count}, &callResult)
-	if err != nil {
-		return GetNameOpsAffectedAtResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetNameOpsAffectedAtResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetNameOpsAffectedAtResult{}, rpcError
-	}
+	return bsk.GetNameOpsAffectedAtContext(context.Background(), blockID, offset, count)
+}
 
+// GetNameOpsAffectedAtContext calls the get_nameops_affected_at RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetNameOpsAffectedAtContext(ctx context.Context, blockID, offset, count int) (GetNameOpsAffectedAtResult, Error) {
 	var out GetNameOpsAffectedAtResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetNameOpsAffectedAtResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_nameops_affected_at", []interface{}{blockID, offset, count}, &out)
+	return out, err
 }
 
 // GetConsensusHashes calls the get_consensus_hashes RPC method for blockstack server
 func (bsk *Client) GetConsensusHashes(blocks []int) (GetConsensusHashesResult, Error) {
-	rpcCall := "get_consensus_hashes"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{blocks}, &callResult)
-	if err != nil {
-		return GetConsensusHashesResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return GetConsensusHashesResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return GetConsensusHashesResult{}, rpcError
-	}
+	return bsk.GetConsensusHashesContext(context.Background(), blocks)
+}
 
+// GetConsensusHashesContext calls the get_consensus_hashes RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetConsensusHashesContext(ctx context.Context, blocks []int) (GetConsensusHashesResult, Error) {
 	var out GetConsensusHashesResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return GetConsensusHashesResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_consensus_hashes", []interface{}{blocks}, &out)
+	return out, err
 }
 
 // GetNumOpHistoryRows calls the get_num_op_history_rows RPC method for blockstack server
 func (bsk *Client) GetNumOpHistoryRows(historyID string) (CountResult, Error) {
-	rpcCall := "get_num_op_history_rows"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{historyID}, &callResult)
-	if err != nil {
-		return CountResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return CountResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return CountResult{}, rpcError
-	}
+	return bsk.GetNumOpHistoryRowsContext(context.Background(), historyID)
+}
 
+// GetNumOpHistoryRowsContext calls the get_num_op_history_rows RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetNumOpHistoryRowsContext(ctx context.Context, historyID string) (CountResult, Error) {
 	var out CountResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return CountResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_num_op_history_rows", []interface{}{historyID}, &out)
+	return out, err
 }
 
 // GetNumNameOpsAffectedAt calls the get_num_nameops_affected_at RPC method for blockstack server
 func (bsk *Client) GetNumNameOpsAffectedAt(blockID int) (CountResult, Error) {
-	rpcCall := "get_num_nameops_affected_at"
-	var callResult string
-
-	err := bsk.node.Call(rpcCall, []interface{}{blockID}, &callResult)
-	if err != nil {
-		return CountResult{}, CallError{Err: err, RPC: rpcCall}
-	}
-	var rpcError RPCError
-	err = json.Unmarshal([]byte(callResult), &rpcError)
-	if err != nil {
-		return CountResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	if rpcError.Error() != "" {
-		rpcError.RPC = rpcCall
-		return CountResult{}, rpcError
-	}
+	return bsk.GetNumNameOpsAffectedAtContext(context.Background(), blockID)
+}
 
+// GetNumNameOpsAffectedAtContext calls the get_num_nameops_affected_at RPC method for blockstack server and aborts the call when ctx is done
+func (bsk *Client) GetNumNameOpsAffectedAtContext(ctx context.Context, blockID int) (CountResult, Error) {
 	var out CountResult
-	err = json.Unmarshal([]byte(callResult), &out)
-	if err != nil {
-		return CountResult{}, JSONUnmarshalError{RPC: rpcCall, Err: err}
-	}
-
-	return out, nil
+	err := bsk.rpc(ctx, "get_num_nameops_affected_at", []interface{}{blockID}, &out)
+	return out, err
 }
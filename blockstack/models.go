@@ -4,6 +4,8 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"log"
+	"sort"
+	"strings"
 )
 
 // StartBlock is the first block on the bitcoin blockchain with blockstack transactions
@@ -40,6 +42,7 @@ func (r GetInfoResult) PrettyJSON() string {
 
 // Transaction models a Bitcoin Transaction for various structs here
 type Transaction struct {
+	Name                 string  `json:"name"`
 	ValueHash            string  `json:"value_hash"`
 	LastRenewed          int     `json:"last_renewed"`
 	LastCreationOp       string  `json:"last_creation_op"`
@@ -133,7 +136,7 @@ func (r GetNameBlockchainRecordResult) PrettyJSON() string {
 	return string(byt)
 }
 
-// LastTx returns the last transcation from the history
+// LastTx returns the last transcation from the history, an empty Transaction without history
 func (r GetNameBlockchainRecordResult) LastTx() Transaction {
 	var tx int
 	for block := range r.Record.History {
@@ -141,6 +144,9 @@ func (r GetNameBlockchainRecordResult) LastTx() Transaction {
 			tx = block
 		}
 	}
+	if len(r.Record.History[tx]) == 0 {
+		return Transaction{}
+	}
 	return r.Record.History[tx][0]
 }
 
@@ -701,17 +707,33 @@ type GetZonefilesResult struct {
 	Zonefiles map[string]string `json:"zonefiles"`
 }
 
-// Decode is an affordance that returns the results in map[zonefileHash]zonefile
+// Decode is an affordance that returns the results in map[zonefileHash]zonefile.
+// Zonefiles that do not hash to their zonefileHash are left out
 func (r GetZonefilesResult) Decode() map[string]string {
+	out, _ := r.Verify()
+	return out
+}
+
+// Verify decodes each zonefile and checks that it hashes to the key it was
+// returned under. It returns the zonefiles that do in map[zonefileHash]zonefile
+// and a ZonefileMismatch for each that does not
+func (r GetZonefilesResult) Verify() (map[string]string, []ZonefileMismatch) {
 	out := make(map[string]string)
-	for k := range r.Zonefiles {
-		dec, err := base64.StdEncoding.DecodeString(r.Zonefiles[k])
+	var bad []ZonefileMismatch
+	for hash, enc := range r.Zonefiles {
+		dec, err := base64.StdEncoding.DecodeString(enc)
 		if err != nil {
-			log.Fatal(err)
+			bad = append(bad, ZonefileMismatch{Hash: hash, Err: err.Error()})
+			continue
+		}
+		if actual := ZonefileHash(string(dec)); actual != strings.ToLower(hash) {
+			bad = append(bad, ZonefileMismatch{Hash: hash, Actual: actual, Err: "zonefile does not match its hash"})
+			continue
 		}
-		out[k] = string(dec)
+		out[hash] = string(dec)
 	}
-	return out
+	sort.Slice(bad, func(i, j int) bool { return bad[i].Hash < bad[j].Hash })
+	return out, bad
 }
 
 // JSON returns the JSON representation of GetZonefilesResult
@@ -0,0 +1,379 @@
+package blockstack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Strategy picks which healthy node in a Pool serves the next call
+type Strategy int
+
+const (
+	// RoundRobin cycles through the healthy nodes in order
+	RoundRobin Strategy = iota
+	// LeastLatency picks the healthy node with the lowest average latency
+	LeastLatency
+	// Weighted picks a healthy node at random, weighted by success rate over average latency
+	Weighted
+)
+
+var strategyNames = map[Strategy]string{
+	RoundRobin:   "roundRobin",
+	LeastLatency: "leastLatency",
+	Weighted:     "weighted",
+}
+
+func (s Strategy) String() string {
+	return strategyNames[s]
+}
+
+// ParseStrategy returns the Strategy for one of roundRobin, leastLatency or weighted
+func ParseStrategy(name string) (Strategy, error) {
+	for s, n := range strategyNames {
+		if strings.EqualFold(n, name) {
+			return s, nil
+		}
+	}
+	return RoundRobin, fmt.Errorf("unknown pool strategy %q", name)
+}
+
+const (
+	defaultMaxFailures = 3
+	defaultEjectFor    = 30 * time.Second
+	defaultMaxEjectFor = 10 * time.Minute
+
+	// latencyDecay is the weight of the newest sample in the moving average latency
+	latencyDecay = 0.2
+)
+
+// PoolConfig configures the selection and circuit breaking of a Pool
+type PoolConfig struct {
+	Strategy Strategy `json:"strategy" yaml:"strategy"`
+
+	// MaxFailures is the number of consecutive failed calls after which a node is ejected
+	MaxFailures int `json:"maxFailures" yaml:"maxFailures"`
+
+	// EjectFor is how long a node is ejected before it is probed with GetInfo.
+	// Each failed probe doubles the time up to MaxEjectFor
+	EjectFor    time.Duration `json:"ejectFor" yaml:"ejectFor"`
+	MaxEjectFor time.Duration `json:"maxEjectFor" yaml:"maxEjectFor"`
+}
+
+// Pool spreads calls over a set of blockstack-core nodes. It tracks the error
+// rate and latency of every node through client Hooks, ejects nodes after
+// MaxFailures consecutive failures and re-admits them once a GetInfo probe succeeds.
+// Only failed calls and indexing nodes count as failures, errors returned by
+// blockstack-core for a call such as "Not found." do not
+type Pool struct {
+	config PoolConfig
+	nodes  []*poolNode
+	next   int
+
+	sync.Mutex
+}
+
+// poolNode holds a client and its health
+type poolNode struct {
+	client *Client
+
+	calls        int
+	errors       int
+	failures     int
+	latency      time.Duration
+	ejectedUntil time.Time
+	ejectFor     time.Duration
+	probing      bool
+}
+
+// NodeStats is a snapshot of the health of a node in a Pool
+type NodeStats struct {
+	URL                 string        `json:"url"`
+	Calls               int           `json:"calls"`
+	Errors              int           `json:"errors"`
+	ConsecutiveFailures int           `json:"consecutiveFailures"`
+	Latency             time.Duration `json:"latency"`
+	Ejected             bool          `json:"ejected"`
+	EjectedUntil        time.Time     `json:"ejectedUntil,omitempty"`
+}
+
+// JSON allows for easy Marshal
+func (ns NodeStats) JSON() string {
+	byt, err := json.Marshal(ns)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return string(byt)
+}
+
+// PrettyJSON allows for easy Marshal
+func (ns NodeStats) PrettyJSON() string {
+	byt, err := json.MarshalIndent(ns, "", "    ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return string(byt)
+}
+
+// NewPool returns a Pool of clients. The pool registers hooks on each client so
+// clients should not be in use when they are added
+func NewPool(conf PoolConfig, clients ...*Client) *Pool {
+	if conf.MaxFailures <= 0 {
+		conf.MaxFailures = defaultMaxFailures
+	}
+	if conf.EjectFor <= 0 {
+		conf.EjectFor = defaultEjectFor
+	}
+	if conf.MaxEjectFor < conf.EjectFor {
+		conf.MaxEjectFor = defaultMaxEjectFor
+		if conf.MaxEjectFor < conf.EjectFor {
+			conf.MaxEjectFor = conf.EjectFor
+		}
+	}
+	p := &Pool{config: conf}
+	p.Set(clients)
+	return p
+}
+
+// Set replaces the clients in the pool. The health of nodes that stay in the
+// pool, matched by URL, is kept
+func (p *Pool) Set(clients []*Client) {
+	p.Lock()
+	defer p.Unlock()
+
+	existing := make(map[string]*poolNode, len(p.nodes))
+	for _, n := range p.nodes {
+		existing[n.client.config.String()] = n
+	}
+
+	nodes := make([]*poolNode, 0, len(clients))
+	for _, c := range clients {
+		n, ok := existing[c.config.String()]
+		if !ok {
+			n = &poolNode{ejectFor: p.config.EjectFor}
+		}
+		if n.client != c {
+			n.client = c
+			c.AddHooks(Hooks{After: p.record(n)})
+		}
+		nodes = append(nodes, n)
+	}
+	p.nodes = nodes
+}
+
+// Add adds a client to the pool
+func (p *Pool) Add(c *Client) {
+	p.Lock()
+	clients := make([]*Client, 0, len(p.nodes)+1)
+	for _, n := range p.nodes {
+		clients = append(clients, n.client)
+	}
+	p.Unlock()
+	p.Set(append(clients, c))
+}
+
+// Len returns the number of nodes in the pool, ejected or not
+func (p *Pool) Len() int {
+	p.Lock()
+	defer p.Unlock()
+	return len(p.nodes)
+}
+
+// Healthy returns the number of nodes that are not ejected
+func (p *Pool) Healthy() int {
+	p.Lock()
+	defer p.Unlock()
+	return len(p.healthy())
+}
+
+// Clients returns every client in the pool, ejected or not
+func (p *Pool) Clients() []*Client {
+	p.Lock()
+	defer p.Unlock()
+	out := make([]*Client, 0, len(p.nodes))
+	for _, n := range p.nodes {
+		out = append(out, n.client)
+	}
+	return out
+}
+
+// Stats returns the health of every node in the pool
+func (p *Pool) Stats() []NodeStats {
+	p.Lock()
+	defer p.Unlock()
+	out := make([]NodeStats, 0, len(p.nodes))
+	for _, n := range p.nodes {
+		ns := NodeStats{
+			URL:                 n.client.config.String(),
+			Calls:               n.calls,
+			Errors:              n.errors,
+			ConsecutiveFailures: n.failures,
+			Latency:             n.latency,
+			Ejected:             !n.ejectedUntil.IsZero(),
+		}
+		if ns.Ejected {
+			ns.EjectedUntil = n.ejectedUntil
+		}
+		out = append(out, ns)
+	}
+	return out
+}
+
+// Next returns the client that should serve the next call. Ejected nodes whose
+// time is up are probed in the background. If every node is ejected the one
+// that will be probed soonest is returned. Next returns nil for an empty pool
+func (p *Pool) Next() *Client {
+	p.Lock()
+	defer p.Unlock()
+
+	if len(p.nodes) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for _, n := range p.nodes {
+		if n.ejected(now) || n.ejectedUntil.IsZero() || n.probing {
+			continue
+		}
+		n.probing = true
+		go p.probe(n)
+	}
+
+	healthy := p.healthy()
+	if len(healthy) == 0 {
+		soonest := p.nodes[0]
+		for _, n := range p.nodes[1:] {
+			if n.ejectedUntil.Before(soonest.ejectedUntil) {
+				soonest = n
+			}
+		}
+		return soonest.client
+	}
+
+	switch p.config.Strategy {
+	case LeastLatency:
+		best := healthy[0]
+		for _, n := range healthy[1:] {
+			if n.latency < best.latency {
+				best = n
+			}
+		}
+		return best.client
+	case Weighted:
+		return p.weighted(healthy).client
+	default:
+		p.next = (p.next + 1) % len(healthy)
+		return healthy[p.next].client
+	}
+}
+
+// healthy returns the nodes that are neither ejected nor waiting on a probe
+func (p *Pool) healthy() []*poolNode {
+	var out []*poolNode
+	for _, n := range p.nodes {
+		if n.ejectedUntil.IsZero() {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// weighted picks a node at random with probability proportional to
+// its success rate divided by its average latency
+func (p *Pool) weighted(nodes []*poolNode) *poolNode {
+	weights := make([]float64, len(nodes))
+	var total float64
+	for i, n := range nodes {
+		success := 1.0
+		if n.calls > 0 {
+			success = float64(n.calls-n.errors+1) / float64(n.calls+1)
+		}
+		latency := n.latency.Seconds()
+		if latency <= 0 {
+			latency = 0.001
+		}
+		weights[i] = success / latency
+		total += weights[i]
+	}
+	r := rand.Float64() * total
+	for i, w := range weights {
+		r -= w
+		if r <= 0 {
+			return nodes[i]
+		}
+	}
+	return nodes[len(nodes)-1]
+}
+
+// record returns the After hook that updates the health of n
+func (p *Pool) record(n *poolNode) func(context.Context, string, interface{}, time.Duration, Error) {
+	return func(ctx context.Context, method string, args interface{}, took time.Duration, err Error) {
+		p.Lock()
+		defer p.Unlock()
+
+		n.calls++
+		if n.latency == 0 {
+			n.latency = took
+		} else {
+			n.latency = time.Duration(latencyDecay*float64(took) + (1-latencyDecay)*float64(n.latency))
+		}
+
+		if !failed(err) {
+			n.failures = 0
+			n.ejectedUntil = time.Time{}
+			n.ejectFor = p.config.EjectFor
+			return
+		}
+
+		n.errors++
+		n.failures++
+		if n.failures < p.config.MaxFailures {
+			return
+		}
+		now := time.Now()
+		switch {
+		case n.ejected(now):
+			// A call made before the node was ejected
+			return
+		case !n.ejectedUntil.IsZero():
+			// A failed probe, back off before the next one
+			n.ejectFor *= 2
+			if n.ejectFor > p.config.MaxEjectFor {
+				n.ejectFor = p.config.MaxEjectFor
+			}
+		default:
+			log.Printf("[pool] ejecting %s for %v after %d consecutive failures: %v", n.client.config, n.ejectFor, n.failures, err)
+		}
+		n.ejectedUntil = now.Add(n.ejectFor)
+	}
+}
+
+// probe checks an ejected node with GetInfo. The hook on the client re-admits it on success
+func (p *Pool) probe(n *poolNode) {
+	_, err := n.client.GetInfo()
+	p.Lock()
+	n.probing = false
+	p.Unlock()
+	if !failed(err) {
+		log.Printf("[pool] re-admitting %s", n.client.config)
+	}
+}
+
+// failed reports whether err means the node is unhealthy
+func failed(err Error) bool {
+	switch err.(type) {
+	case CallError, IndexingError, ZonefileHashError:
+		return true
+	}
+	return false
+}
+
+// ejected reports whether n is ejected and not yet due for a probe at now
+func (n *poolNode) ejected(now time.Time) bool {
+	return now.Before(n.ejectedUntil)
+}
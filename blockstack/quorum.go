@@ -0,0 +1,125 @@
+package blockstack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// quorumReply is the answer of one node to a quorum read
+type quorumReply struct {
+	client    *Client
+	record    GetNameBlockchainRecordResult
+	err       Error
+	lastblock int
+	key       string
+}
+
+// GetNameBlockchainRecordQuorum asks every healthy node in the pool for the
+// name record and returns it only when at least n of them agree on both the
+// record and the consensus hash at the lowest lastblock they report. Errors
+// from blockstack-core like "Not found." and status false are agreed on like
+// any other answer, also at that consensus hash, and returned when n nodes
+// give them. A QuorumError is returned when too few nodes agree
+func (p *Pool) GetNameBlockchainRecordQuorum(ctx context.Context, name string, n int) (GetNameBlockchainRecordResult, Error) {
+	method := "get_name_blockchain_record"
+	clients := p.healthyClients()
+
+	// Fetch the record from every node
+	replies := make([]*quorumReply, len(clients))
+	var wg sync.WaitGroup
+	for i, c := range clients {
+		wg.Add(1)
+		go func(i int, c *Client) {
+			defer wg.Done()
+			r := &quorumReply{client: c}
+			r.record, r.err = c.GetNameBlockchainRecordContext(ctx, name)
+			r.lastblock = r.record.Lastblock
+			// Errors do not say which block the node is at
+			if _, ok := r.err.(RPCError); ok {
+				info, err := c.GetInfoContext(ctx)
+				if err != nil {
+					r.lastblock = -1
+				} else {
+					r.lastblock = info.LastBlockProcessed
+				}
+			}
+			replies[i] = r
+		}(i, c)
+	}
+	wg.Wait()
+
+	// Records, status false and errors from blockstack-core such as
+	// "Not found." count towards the quorum, failed calls do not
+	var answered []*quorumReply
+	block := 0
+	for _, r := range replies {
+		switch r.err.(type) {
+		case nil, StatusError, RPCError:
+		default:
+			continue
+		}
+		if r.lastblock < 0 {
+			continue
+		}
+		if block == 0 || r.lastblock < block {
+			block = r.lastblock
+		}
+		answered = append(answered, r)
+	}
+
+	// Check every node that answered agrees on the state of the chain at block
+	for _, r := range answered {
+		wg.Add(1)
+		go func(r *quorumReply) {
+			defer wg.Done()
+			ch, err := r.client.GetConsensusAtContext(ctx, block)
+			if err != nil {
+				return
+			}
+			if rpcErr, ok := r.err.(RPCError); ok {
+				r.key = fmt.Sprintf("%s:error:%s", ch.Consensus, rpcErr.Error())
+				return
+			}
+			byt, e := json.Marshal(r.record.Record)
+			if e != nil {
+				return
+			}
+			r.key = fmt.Sprintf("%s:%t:%s", ch.Consensus, r.record.Status, byt)
+		}(r)
+	}
+	wg.Wait()
+
+	votes := make(map[string]int)
+	var best *quorumReply
+	for _, r := range replies {
+		if r.key == "" {
+			continue
+		}
+		votes[r.key]++
+		if best == nil || votes[r.key] > votes[best.key] {
+			best = r
+		}
+	}
+
+	if best == nil || votes[best.key] < n {
+		qe := QuorumError{RPC: method, Required: n, Nodes: len(clients), Lastblock: block}
+		if best != nil {
+			qe.Agreed = votes[best.key]
+		}
+		return GetNameBlockchainRecordResult{}, qe
+	}
+	return best.record, best.err
+}
+
+// healthyClients returns the clients of nodes that are not ejected
+func (p *Pool) healthyClients() []*Client {
+	p.Lock()
+	defer p.Unlock()
+	var out []*Client
+	for _, n := range p.healthy() {
+		out = append(out, n.client)
+	}
+	return out
+}
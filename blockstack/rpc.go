@@ -0,0 +1,96 @@
+package blockstack
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Hooks are run around every RPC call a Client makes and can be used to add
+// logging, metrics and tracing to all the RPC methods at once
+type Hooks struct {
+	// Before is called before the call is made. The returned context
+	// is used for the call and passed to After, nil keeps ctx
+	Before func(ctx context.Context, method string, args interface{}) context.Context
+
+	// After is called when the call returns with its duration and error, if any
+	After func(ctx context.Context, method string, args interface{}, took time.Duration, err Error)
+}
+
+// AddHooks registers hooks on the client. Hooks run in the order they
+// were added and should be registered before the client is used
+func (bsk *Client) AddHooks(h Hooks) {
+	bsk.hooks = append(bsk.hooks, h)
+}
+
+// resultStatus holds the fields common to most blockstack-core results
+type resultStatus struct {
+	Status    json.RawMessage `json:"status"`
+	Indexing  bool            `json:"indexing"`
+	Lastblock int             `json:"lastblock"`
+}
+
+// rpc is the single call path for the RPC methods. It makes the call, checks
+// for an error from blockstack-core and unmarshals the result into out.
+// If the node is indexing or returns status false out is still populated and
+// an IndexingError or StatusError is returned with it
+func (bsk *Client) rpc(ctx context.Context, method string, args interface{}, out interface{}) Error {
+	for _, h := range bsk.hooks {
+		if h.Before != nil {
+			if c := h.Before(ctx, method, args); c != nil {
+				ctx = c
+			}
+		}
+	}
+
+	start := time.Now()
+	err := bsk.decode(ctx, method, args, out)
+	took := time.Since(start)
+
+	for _, h := range bsk.hooks {
+		if h.After != nil {
+			h.After(ctx, method, args, took, err)
+		}
+	}
+	return err
+}
+
+// decode makes the call and turns the JSON result into out or a typed Error
+func (bsk *Client) decode(ctx context.Context, method string, args interface{}, out interface{}) Error {
+	var callResult string
+	err := bsk.call(ctx, method, args, &callResult)
+	if err != nil {
+		return CallError{Err: err, RPC: method}
+	}
+
+	var rpcError RPCError
+	err = json.Unmarshal([]byte(callResult), &rpcError)
+	if err != nil {
+		return JSONUnmarshalError{RPC: method, Err: err}
+	}
+
+	if rpcError.Error() != "" {
+		rpcError.RPC = method
+		return rpcError
+	}
+
+	err = json.Unmarshal([]byte(callResult), out)
+	if err != nil {
+		return JSONUnmarshalError{RPC: method, Err: err}
+	}
+
+	// ping returns a string status, only a boolean false is a failure
+	var status resultStatus
+	err = json.Unmarshal([]byte(callResult), &status)
+	if err != nil {
+		return JSONUnmarshalError{RPC: method, Err: err}
+	}
+	if status.Indexing {
+		return IndexingError{RPC: method, Lastblock: status.Lastblock}
+	}
+	if string(status.Status) == "false" {
+		return StatusError{RPC: method, Lastblock: status.Lastblock}
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package blockstack
+
+// Statuses of a name returned by NameStatus
+const (
+	StatusAvailable     = "available"
+	StatusPending       = "pending"
+	StatusRegistered    = "registered"
+	StatusExpired       = "expired"
+	StatusRevoked       = "revoked"
+	StatusInGracePeriod = "in-grace-period"
+)
+
+// NameStatus derives the status of a name from its record at r.Lastblock.
+// A name is in its grace period from its expire block until its renewal
+// deadline, when it can still be renewed by its owner. Names in namespaces
+// that never expire have no expire block
+func NameStatus(r GetNameBlockchainRecordResult) string {
+	rec := r.Record
+	switch {
+	case !r.Status:
+		return StatusAvailable
+	case rec.Opcode == "NAME_PREORDER" || r.LastTx().Opcode == "NAME_PREORDER":
+		return StatusPending
+	case rec.Revoked:
+		return StatusRevoked
+	case rec.ExpireBlock > 0 && r.Lastblock >= rec.ExpireBlock:
+		if rec.RenewalDeadline > rec.ExpireBlock && r.Lastblock < rec.RenewalDeadline {
+			return StatusInGracePeriod
+		}
+		return StatusExpired
+	case rec.Expired:
+		return StatusExpired
+	}
+	return StatusRegistered
+}
@@ -0,0 +1,79 @@
+package blockstack
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/kolo/xmlrpc"
+)
+
+// Caller makes RPC calls against a blockstack-core node. The reply for every
+// blockstack-core method is a JSON encoded string so reply is always a *string.
+// NewClient uses an XML-RPC over HTTP implementation, NewClientWithCaller
+// allows swapping it out, for example with the fake core in blockstacktest
+type Caller interface {
+	Call(ctx context.Context, method string, args interface{}, reply interface{}) error
+}
+
+// httpNode makes XML-RPC calls to a blockstack-core node over HTTP.
+// Unlike *xmlrpc.Client each call carries a context.Context so that
+// in-flight requests can be bounded and aborted by the caller
+type httpNode struct {
+	url    string
+	client *http.Client
+}
+
+// newHTTPNode returns an *httpNode using the Transport and Timeout from conf
+func newHTTPNode(conf ServerConfig) *httpNode {
+	transport := conf.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &httpNode{
+		url: conf.String(),
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   conf.Timeout,
+		},
+	}
+}
+
+// Call makes the XML-RPC call and unmarshals the result into reply
+func (n *httpNode) Call(ctx context.Context, method string, args interface{}, reply interface{}) error {
+	req, err := xmlrpc.NewRequest(n.url, method, args)
+	if err != nil {
+		return err
+	}
+
+	res, err := n.client.Do(req.WithContext(ctx))
+	if err != nil {
+		// Prefer the context error so callers can check for cancellation
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("request error: bad status code - %d", res.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	resp := xmlrpc.Response(body)
+	if err := resp.Err(); err != nil {
+		return err
+	}
+	return resp.Unmarshal(reply)
+}
+
+// call runs an RPC method against the node backing the client
+func (bsk *Client) call(ctx context.Context, method string, args interface{}, reply interface{}) error {
+	return bsk.node.Call(ctx, method, args, reply)
+}
@@ -0,0 +1,16 @@
+package blockstack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"golang.org/x/crypto/ripemd160"
+)
+
+// ZonefileHash returns the hex encoded RIPEMD160(SHA256(zonefile)) used as a name's value_hash
+func ZonefileHash(zonefile string) string {
+	sha := sha256.Sum256([]byte(zonefile))
+	h := ripemd160.New()
+	h.Write(sha[:])
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -46,7 +46,15 @@ func init() {
 	cobra.OnInitialize(initConfig)
 	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.blockstack-api.yaml)")
 	RootCmd.PersistentFlags().IntVar(&port, "port", 3000, "the port to run the blockstack server on")
+	RootCmd.PersistentFlags().StringSlice("hosts", []string{"https://node.blockstack.org:6263"}, "blockstack-core nodes to serve the api from")
+	RootCmd.PersistentFlags().Int("quorum", 0, "number of nodes that must agree on a name record before it is returned, 0 reads from a single node")
+	RootCmd.PersistentFlags().String("clientStrategy", "roundRobin", "how to pick a blockstack-core node for each call: roundRobin, leastLatency or weighted")
+	RootCmd.PersistentFlags().Int("updateInterval", 5, "how frequently in minutes to check which nodes are in consensus")
 	viper.BindPFlag("port", RootCmd.PersistentFlags().Lookup("port"))
+	viper.BindPFlag("hosts", RootCmd.PersistentFlags().Lookup("hosts"))
+	viper.BindPFlag("quorum", RootCmd.PersistentFlags().Lookup("quorum"))
+	viper.BindPFlag("clientStrategy", RootCmd.PersistentFlags().Lookup("clientStrategy"))
+	viper.BindPFlag("updateInterval", RootCmd.PersistentFlags().Lookup("updateInterval"))
 }
 
 func initConfig() {
@@ -19,9 +19,11 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/blockstack/blockstack.go/api"
 	"github.com/blockstack/blockstack.go/blockstack"
+	"github.com/blockstack/blockstack.go/indexer"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -31,8 +33,38 @@ var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "This serves the blockstack api",
 	Run: func(cmd *cobra.Command, args []string) {
-		// TODO: Implement the same multiclient thing here that I did over in the indexer
-		router := api.NewRouter(blockstack.ServerConfig{Address: "node.blockstack.org", Port: "6263", Scheme: "https"})
+		strategy, err := blockstack.ParseStrategy(viper.GetString("clientStrategy"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		conf := api.Config{
+			URLs:                 viper.GetStringSlice("hosts"),
+			Pool:                 blockstack.PoolConfig{Strategy: strategy},
+			Quorum:               viper.GetInt("quorum"),
+			ClientUpdateInterval: time.Duration(viper.GetInt("updateInterval")) * time.Minute,
+			MaxIndexLag:          viper.GetInt("maxIndexLag"),
+			ProofEndpoint:        viper.GetString("proofEndpoint"),
+		}
+		if viper.GetBool("index") {
+			conf.Index = &indexer.Config{
+				URLs:                 conf.URLs,
+				IndexMethod:          viper.GetString("indexMethod"),
+				NamePageWorkers:      10,
+				ResolveWorkers:       50,
+				ConcurrentPageFetch:  100,
+				DBWorkers:            4,
+				ClientUpdateInterval: viper.GetInt("updateInterval"),
+				Store:                viper.GetString("store"),
+				StorePath:            viper.GetString("storePath"),
+				MongoConnection:      viper.GetString("mongoConn"),
+				PollInterval:         viper.GetDuration("pollInterval"),
+				CheckProofs:          viper.GetBool("checkProofs"),
+				ProofEndpoint:        conf.ProofEndpoint,
+				Pool:                 conf.Pool,
+			}
+			log.Println(conf.Index)
+		}
+		router := api.NewRouter(conf)
 		log.Println("Serving the blockstack-api on port", viper.GetInt("port"))
 		log.Fatal(http.ListenAndServe(fmt.Sprintf(":%v", viper.GetInt("port")), router))
 
@@ -42,13 +74,22 @@ var serveCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(serveCmd)
 
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// serveCmd.PersistentFlags().String("foo", "", "A help for foo")
-
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// serveCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	serveCmd.Flags().Bool("index", false, "run an indexer and serve names, profiles, owners and namespace listings from its store")
+	serveCmd.Flags().Int("maxIndexLag", 6, "blocks the index can be behind the chain tip before requests go to blockstack-core")
+	serveCmd.Flags().String("indexMethod", "byName", "indexing method to employ: byName or byBlock")
+	serveCmd.Flags().String("store", "mongo", "where to keep the index: mongo, bolt or memory")
+	serveCmd.Flags().String("storePath", "blockstack-index.db", "the BoltDB file to keep the index in with --store bolt")
+	serveCmd.Flags().String("mongoConn", "localhost", "a connection string to a mongodb instance")
+	serveCmd.Flags().Duration("pollInterval", 30*time.Second, "how often the indexer checks for new blocks")
+	serveCmd.Flags().Bool("checkProofs", false, "have the indexer fetch and check the social proofs of every profile resolved")
+	serveCmd.Flags().String("proofEndpoint", "", "fetch social proofs from this URL instead of each service's host")
+	viper.BindPFlag("index", serveCmd.Flags().Lookup("index"))
+	viper.BindPFlag("maxIndexLag", serveCmd.Flags().Lookup("maxIndexLag"))
+	viper.BindPFlag("indexMethod", serveCmd.Flags().Lookup("indexMethod"))
+	viper.BindPFlag("store", serveCmd.Flags().Lookup("store"))
+	viper.BindPFlag("storePath", serveCmd.Flags().Lookup("storePath"))
+	viper.BindPFlag("mongoConn", serveCmd.Flags().Lookup("mongoConn"))
+	viper.BindPFlag("pollInterval", serveCmd.Flags().Lookup("pollInterval"))
+	viper.BindPFlag("checkProofs", serveCmd.Flags().Lookup("checkProofs"))
+	viper.BindPFlag("proofEndpoint", serveCmd.Flags().Lookup("proofEndpoint"))
 }
@@ -0,0 +1,67 @@
+// Copyright © 2017 Jack Zampolin <jack.zampolin@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var cfgFile string
+
+// RootCmd represents the base command when called without any subcommands
+var RootCmd = &cobra.Command{
+	Use:   "blockstack-dns",
+	Short: "An authoritative DNS server for blockstack names that answers from their zonefiles",
+}
+
+func Execute() {
+	if err := RootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	cobra.OnInitialize(initConfig)
+	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.blockstack-dns.yaml)")
+	RootCmd.PersistentFlags().StringSlice("hosts", []string{"https://node.blockstack.org:6263"}, "blockstack-core nodes to look names up on")
+	RootCmd.PersistentFlags().String("clientStrategy", "roundRobin", "how to pick a blockstack-core node for each call: roundRobin, leastLatency or weighted")
+	RootCmd.PersistentFlags().Int("updateInterval", 5, "how frequently in minutes to check which nodes are in consensus")
+	viper.BindPFlag("hosts", RootCmd.PersistentFlags().Lookup("hosts"))
+	viper.BindPFlag("clientStrategy", RootCmd.PersistentFlags().Lookup("clientStrategy"))
+	viper.BindPFlag("updateInterval", RootCmd.PersistentFlags().Lookup("updateInterval"))
+}
+
+func initConfig() {
+	if cfgFile != "" {
+		viper.SetConfigFile(cfgFile)
+	} else {
+		home, err := homedir.Dir()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		viper.AddConfigPath(home)
+		viper.SetConfigName(".blockstack-dns")
+	}
+	viper.AutomaticEnv()
+	viper.ReadInConfig()
+}
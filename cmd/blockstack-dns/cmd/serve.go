@@ -0,0 +1,84 @@
+// Copyright © 2017 Jack Zampolin <jack.zampolin@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"log"
+	"time"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+	"github.com/blockstack/blockstack.go/dnsgateway"
+	"github.com/blockstack/blockstack.go/indexer"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve DNS for the blockstack namespaces over UDP and TCP",
+	Run: func(cmd *cobra.Command, args []string) {
+		strategy, err := blockstack.ParseStrategy(viper.GetString("clientStrategy"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		conf := dnsgateway.Config{
+			Addr:                 viper.GetString("addr"),
+			Namespaces:           viper.GetStringSlice("namespaces"),
+			URLs:                 viper.GetStringSlice("hosts"),
+			Pool:                 blockstack.PoolConfig{Strategy: strategy},
+			ClientUpdateInterval: time.Duration(viper.GetInt("updateInterval")) * time.Minute,
+			PollInterval:         viper.GetDuration("pollInterval"),
+			CacheSize:            viper.GetInt("cacheSize"),
+		}
+		if store := viper.GetString("store"); store != "" {
+			conf.Store, err = indexer.NewStore(&indexer.Config{
+				Store:           store,
+				StorePath:       viper.GetString("storePath"),
+				MongoConnection: viper.GetString("mongoConn"),
+			})
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer conf.Store.Close()
+		}
+		srv, err := dnsgateway.NewServer(conf)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Println("Serving DNS for", conf.Namespaces, "on", conf.Addr)
+		log.Fatal(srv.ListenAndServe())
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().String("addr", ":53", "the address to serve DNS on over UDP and TCP")
+	serveCmd.Flags().StringSlice("namespaces", []string{"id"}, "the namespaces to answer for")
+	serveCmd.Flags().Duration("pollInterval", 30*time.Second, "how often to check for new blocks, which empties the response cache")
+	serveCmd.Flags().Int("cacheSize", 10000, "the most names to keep answers cached for")
+	serveCmd.Flags().String("store", "", "look names up in an indexer store before blockstack-core: mongo, or bolt when no indexer holds the file")
+	serveCmd.Flags().String("storePath", "blockstack-index.db", "the BoltDB file the index is kept in with --store bolt")
+	serveCmd.Flags().String("mongoConn", "localhost", "a connection string to a mongodb instance")
+	viper.BindPFlag("addr", serveCmd.Flags().Lookup("addr"))
+	viper.BindPFlag("namespaces", serveCmd.Flags().Lookup("namespaces"))
+	viper.BindPFlag("pollInterval", serveCmd.Flags().Lookup("pollInterval"))
+	viper.BindPFlag("cacheSize", serveCmd.Flags().Lookup("cacheSize"))
+	viper.BindPFlag("store", serveCmd.Flags().Lookup("store"))
+	viper.BindPFlag("storePath", serveCmd.Flags().Lookup("storePath"))
+	viper.BindPFlag("mongoConn", serveCmd.Flags().Lookup("mongoConn"))
+}
@@ -18,6 +18,7 @@ package cmd
 import (
 	"log"
 	"os"
+	"time"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
@@ -32,6 +33,11 @@ var (
 	resolveWorkers  int
 	dbBatchSize     int
 	dbWorkers       int
+	updateInterval  int
+	maxAttempts     int
+	retryBackoff    time.Duration
+	retryMaxBackoff time.Duration
+	clientStrategy  string
 	indexMethod     string
 	hosts           []string
 	port            int
@@ -57,15 +63,31 @@ func init() {
 	cobra.OnInitialize(initConfig)
 	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.blockstack-indexer.yaml)")
 	RootCmd.PersistentFlags().StringVar(&mongoConn, "mongoConn", "localhost", "a connection string to a mongodb instance")
+	RootCmd.PersistentFlags().String("store", "mongo", "where to keep the index: mongo, bolt or memory")
+	RootCmd.PersistentFlags().String("storePath", "blockstack-index.db", "the BoltDB file to keep the index in with --store bolt")
 	RootCmd.PersistentFlags().StringSlice("hosts", []string{"https://node.blockstack.org:6263"}, "blockstack-core nodes to run indexer against")
 	RootCmd.PersistentFlags().IntVar(&port, "port", 3000, "port to run the prometheus metrics server on")
 	RootCmd.PersistentFlags().IntVar(&pageFetchConc, "pageFetchConc", 100, "number of namePages to fetch concurrently")
 	RootCmd.PersistentFlags().IntVar(&namePageWorkers, "namePageWorkers", 10, "number of workers to process namePages")
 	RootCmd.PersistentFlags().IntVar(&resolveWorkers, "resolveWorkers", 50, "number of workers to resolve names")
-	RootCmd.PersistentFlags().StringVar(&indexMethod, "indexMethod", "byName", "indexing method to employ")
+	RootCmd.PersistentFlags().StringVar(&indexMethod, "indexMethod", "byName", "indexing method to employ: byName or byBlock")
 	RootCmd.PersistentFlags().IntVar(&dbBatchSize, "dbBatchSize", 20, "number of names to insert/update at same time")
 	RootCmd.PersistentFlags().IntVar(&dbWorkers, "dbWorkers", 4, "number of workers to manage inserts into database")
-	RootCmd.PersistentFlags().IntVar(&dbWorkers, "updateInterval", 5, "how frequently to update clients")
+	RootCmd.PersistentFlags().IntVar(&updateInterval, "updateInterval", 5, "how frequently to update clients")
+	RootCmd.PersistentFlags().IntVar(&maxAttempts, "maxAttempts", 5, "number of times to try each blockstack-core call before giving up")
+	RootCmd.PersistentFlags().DurationVar(&retryBackoff, "retryBackoff", 500*time.Millisecond, "delay before the first retry of a failed blockstack-core call, doubled on each retry")
+	RootCmd.PersistentFlags().DurationVar(&retryMaxBackoff, "retryMaxBackoff", 30*time.Second, "maximum delay between retries of a failed blockstack-core call")
+	RootCmd.PersistentFlags().Duration("pollInterval", 30*time.Second, "how often to check for new blocks once the index is built, 0 to stop after building it")
+	RootCmd.PersistentFlags().Duration("shutdownTimeout", time.Minute, "how long to wait for names in progress to be written on shutdown")
+	RootCmd.PersistentFlags().Duration("dbFlushInterval", time.Second, "how long names wait for a full batch before they are written to the database")
+	RootCmd.PersistentFlags().StringVar(&clientStrategy, "clientStrategy", "roundRobin", "how to pick a blockstack-core node for each call: roundRobin, leastLatency or weighted")
+	RootCmd.PersistentFlags().Int("maxFailures", 3, "consecutive failed calls after which a blockstack-core node is ejected")
+	RootCmd.PersistentFlags().Duration("ejectFor", 30*time.Second, "how long an ejected blockstack-core node waits before a health check")
+	RootCmd.PersistentFlags().Bool("checkProofs", false, "fetch and check the social proofs of every profile resolved")
+	RootCmd.PersistentFlags().Duration("profileTimeout", 10*time.Second, "how long to wait for a profile to be fetched")
+	RootCmd.PersistentFlags().Int64("profileMaxSize", 1<<20, "the largest profile fetched in bytes")
+	RootCmd.PersistentFlags().Int("profileHostConcurrency", 8, "number of profiles fetched from one host at once")
+	RootCmd.PersistentFlags().String("proofEndpoint", "", "fetch social proofs from this URL instead of each service's host")
 	viper.BindPFlag("port", RootCmd.PersistentFlags().Lookup("port"))
 	viper.BindPFlag("hosts", RootCmd.PersistentFlags().Lookup("hosts"))
 	viper.BindPFlag("pageFetchConc", RootCmd.PersistentFlags().Lookup("pageFetchConc"))
@@ -76,6 +98,22 @@ func init() {
 	viper.BindPFlag("dbWorkers", RootCmd.PersistentFlags().Lookup("dbWorkers"))
 	viper.BindPFlag("updateInterval", RootCmd.PersistentFlags().Lookup("updateInterval"))
 	viper.BindPFlag("mongoConn", RootCmd.PersistentFlags().Lookup("mongoConn"))
+	viper.BindPFlag("store", RootCmd.PersistentFlags().Lookup("store"))
+	viper.BindPFlag("storePath", RootCmd.PersistentFlags().Lookup("storePath"))
+	viper.BindPFlag("maxAttempts", RootCmd.PersistentFlags().Lookup("maxAttempts"))
+	viper.BindPFlag("retryBackoff", RootCmd.PersistentFlags().Lookup("retryBackoff"))
+	viper.BindPFlag("retryMaxBackoff", RootCmd.PersistentFlags().Lookup("retryMaxBackoff"))
+	viper.BindPFlag("pollInterval", RootCmd.PersistentFlags().Lookup("pollInterval"))
+	viper.BindPFlag("shutdownTimeout", RootCmd.PersistentFlags().Lookup("shutdownTimeout"))
+	viper.BindPFlag("dbFlushInterval", RootCmd.PersistentFlags().Lookup("dbFlushInterval"))
+	viper.BindPFlag("clientStrategy", RootCmd.PersistentFlags().Lookup("clientStrategy"))
+	viper.BindPFlag("maxFailures", RootCmd.PersistentFlags().Lookup("maxFailures"))
+	viper.BindPFlag("ejectFor", RootCmd.PersistentFlags().Lookup("ejectFor"))
+	viper.BindPFlag("checkProofs", RootCmd.PersistentFlags().Lookup("checkProofs"))
+	viper.BindPFlag("profileTimeout", RootCmd.PersistentFlags().Lookup("profileTimeout"))
+	viper.BindPFlag("profileMaxSize", RootCmd.PersistentFlags().Lookup("profileMaxSize"))
+	viper.BindPFlag("profileHostConcurrency", RootCmd.PersistentFlags().Lookup("profileHostConcurrency"))
+	viper.BindPFlag("proofEndpoint", RootCmd.PersistentFlags().Lookup("proofEndpoint"))
 }
 
 func initConfig() {
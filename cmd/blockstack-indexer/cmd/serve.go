@@ -16,10 +16,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/blockstack/blockstack.go/blockstack"
 	"github.com/blockstack/blockstack.go/indexer"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
@@ -31,32 +37,78 @@ var serveCmd = &cobra.Command{
 	Short: "starts the indexer and serves the metrics server",
 	Run: func(cmd *cobra.Command, args []string) {
 		prt := viper.GetString("port")
+		strategy, err := blockstack.ParseStrategy(viper.GetString("clientStrategy"))
+		if err != nil {
+			log.Fatal(serveLog, err)
+		}
 
 		cfg := &indexer.Config{
-			IndexMethod:          viper.GetString("indexMethod"),
-			NamePageWorkers:      viper.GetInt("namePageWorkers"),
-			ResolveWorkers:       viper.GetInt("resolveWorkers"),
-			ConcurrentPageFetch:  viper.GetInt("pageFetchConc"),
-			DBBatchSize:          viper.GetInt("dbBatchSize"),
-			DBWorkers:            viper.GetInt("dbWorkers"),
-			URLs:                 viper.GetStringSlice("hosts"),
-			ClientUpdateInterval: viper.GetInt("updateInterval"),
-			MongoConnection:      viper.GetString("mongoConn"),
+			IndexMethod:            viper.GetString("indexMethod"),
+			NamePageWorkers:        viper.GetInt("namePageWorkers"),
+			ResolveWorkers:         viper.GetInt("resolveWorkers"),
+			ConcurrentPageFetch:    viper.GetInt("pageFetchConc"),
+			DBBatchSize:            viper.GetInt("dbBatchSize"),
+			DBWorkers:              viper.GetInt("dbWorkers"),
+			DBFlushInterval:        viper.GetDuration("dbFlushInterval"),
+			URLs:                   viper.GetStringSlice("hosts"),
+			ClientUpdateInterval:   viper.GetInt("updateInterval"),
+			MongoConnection:        viper.GetString("mongoConn"),
+			Store:                  viper.GetString("store"),
+			StorePath:              viper.GetString("storePath"),
+			MaxAttempts:            viper.GetInt("maxAttempts"),
+			RetryBackoff:           viper.GetDuration("retryBackoff"),
+			RetryMaxBackoff:        viper.GetDuration("retryMaxBackoff"),
+			PollInterval:           viper.GetDuration("pollInterval"),
+			CheckProofs:            viper.GetBool("checkProofs"),
+			ProofEndpoint:          viper.GetString("proofEndpoint"),
+			ProfileTimeout:         viper.GetDuration("profileTimeout"),
+			ProfileMaxSize:         viper.GetInt64("profileMaxSize"),
+			ProfileHostConcurrency: viper.GetInt("profileHostConcurrency"),
+			Pool: blockstack.PoolConfig{
+				Strategy:    strategy,
+				MaxFailures: viper.GetInt("maxFailures"),
+				EjectFor:    viper.GetDuration("ejectFor"),
+			},
 		}
 
 		log.Println(serveLog, cfg)
 		log.Println(serveLog, "Setting valid clients...")
 		cfg.SetClients()
-		idx := indexer.NewIndexer(cfg)
-
-		go idx.Start()
+		idx, err := indexer.NewIndexer(cfg)
+		if err != nil {
+			log.Fatalln(serveLog, "Unable to open the store:", err)
+		}
+		defer idx.Store().Close()
 
 		http.Handle("/metrics", promhttp.Handler())
 		log.Printf("%v Serving the prometheus metrics for the indexing service on port :%v...", serveLog, prt)
-		log.Fatal(http.ListenAndServe(fmt.Sprintf(":%v", prt), nil))
+		go func() {
+			log.Fatal(http.ListenAndServe(fmt.Sprintf(":%v", prt), nil))
+		}()
+
+		go stopOnSignal(idx, viper.GetDuration("shutdownTimeout"))
+		idx.Start()
 	},
 }
 
+// stopOnSignal stops the indexer on SIGINT or SIGTERM, a second signal exits immediately
+func stopOnSignal(idx *indexer.Indexer, timeout time.Duration) {
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-sigs
+	log.Printf("%s Received %v, finishing the names in progress. Signal again to exit now", serveLog, sig)
+	go func() {
+		<-sigs
+		log.Fatalln(serveLog, "Exiting without finishing:", idx.Summary())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := idx.Stop(ctx); err != nil {
+		log.Fatalln(serveLog, "Shutdown timed out:", idx.Summary())
+	}
+}
+
 func init() {
 	RootCmd.AddCommand(serveCmd)
 }
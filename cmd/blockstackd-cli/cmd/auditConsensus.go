@@ -0,0 +1,98 @@
+// Copyright © 2017 Jack Zampolin <jack.zampolin@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/blockstack/blockstack.go/audit"
+	"github.com/blockstack/blockstack.go/blockstack"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// auditConsensusCmd represents the audit-consensus command
+var auditConsensusCmd = &cobra.Command{
+	Use:   "audit-consensus",
+	Short: "Recompute the consensus hash of each block from the node's ops hashes and flag the blocks it reports a different one at",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var src audit.Source
+		end := viper.GetInt("auditEnd")
+		if path := viper.GetString("auditFixture"); path != "" {
+			fixture, err := audit.LoadFixture(path)
+			if err != nil {
+				fmt.Println("Unable to load fixture:", err)
+				os.Exit(1)
+			}
+			src = fixture
+		} else {
+			client := clientFor(viper.GetString("node"))
+			src = client
+			if end == 0 {
+				info, err := client.GetInfo()
+				if err != nil {
+					fmt.Println("Unable to get the last block processed by", viper.GetString("node"), err)
+					os.Exit(1)
+				}
+				end = info.LastBlockProcessed
+			}
+		}
+		start := viper.GetInt("auditStart")
+
+		if path := viper.GetString("auditRecord"); path != "" {
+			fixture, err := audit.Record(context.Background(), src, start, end)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			fixture.Node = viper.GetString("node")
+			if err := fixture.Save(path); err != nil {
+				fmt.Println("Unable to save fixture:", err)
+				os.Exit(1)
+			}
+			src = fixture
+		}
+
+		report, err := audit.Audit(context.Background(), src, start, end)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if viper.GetBool("pretty") {
+			fmt.Println(report.JSON())
+		} else {
+			fmt.Println(report.PrettyJSON())
+		}
+		if len(report.Mismatches) > 0 {
+			os.Exit(2)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(auditConsensusCmd)
+	auditConsensusCmd.Flags().Int("start", blockstack.StartBlock, "the first block to audit")
+	auditConsensusCmd.Flags().Int("end", 0, "the last block to audit, 0 for the last block processed by the node")
+	auditConsensusCmd.Flags().String("record", "", "write the hashes fetched from the node to this file so the audit can be run again offline")
+	auditConsensusCmd.Flags().String("fixture", "", "audit the hashes recorded in this file instead of the node")
+	viper.BindPFlag("auditStart", auditConsensusCmd.Flags().Lookup("start"))
+	viper.BindPFlag("auditEnd", auditConsensusCmd.Flags().Lookup("end"))
+	viper.BindPFlag("auditRecord", auditConsensusCmd.Flags().Lookup("record"))
+	viper.BindPFlag("auditFixture", auditConsensusCmd.Flags().Lookup("fixture"))
+}
@@ -0,0 +1,71 @@
+// Copyright © 2017 Jack Zampolin <jack.zampolin@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// consensusDiffCmd represents the consensus-diff command
+var consensusDiffCmd = &cobra.Command{
+	Use:   "consensus-diff [nodes...]",
+	Short: "Find the first block two or more nodes disagree on the consensus hash at and how their name operations differ there",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		var clients []*blockstack.Client
+		end := viper.GetInt("end")
+		for _, node := range args {
+			client := clientFor(node)
+			clients = append(clients, client)
+			if viper.GetInt("end") > 0 {
+				continue
+			}
+			// By default search up to the lowest block every node has processed
+			info, err := client.GetInfo()
+			if err != nil {
+				fmt.Println("Unable to get the last block processed by", node, err)
+				os.Exit(1)
+			}
+			if end == 0 || info.LastBlockProcessed < end {
+				end = info.LastBlockProcessed
+			}
+		}
+		report, err := blockstack.FindFork(context.Background(), clients, viper.GetInt("start"), end)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if viper.GetBool("pretty") {
+			fmt.Println(report.JSON())
+		} else {
+			fmt.Println(report.PrettyJSON())
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(consensusDiffCmd)
+	consensusDiffCmd.Flags().Int("start", blockstack.StartBlock, "the first block to compare")
+	consensusDiffCmd.Flags().Int("end", 0, "the last block to compare, 0 for the lowest last block processed by the nodes")
+	viper.BindPFlag("start", consensusDiffCmd.Flags().Lookup("start"))
+	viper.BindPFlag("end", consensusDiffCmd.Flags().Lookup("end"))
+}
@@ -48,8 +48,13 @@ func Execute() {
 
 // getClient returns the client for the configured node
 func getClient() blockstack.Client {
+	return *clientFor(viper.GetString("node"))
+}
+
+// clientFor returns a client for the node at addr, exiting if it can not be parsed
+func clientFor(addr string) *blockstack.Client {
 	scheme := "http"
-	url, err := url.Parse(viper.GetString("node"))
+	url, err := url.Parse(addr)
 	if err != nil {
 		fmt.Printf("Unable to parse node address: %v\n", err)
 		os.Exit(1)
@@ -62,7 +67,7 @@ func getClient() blockstack.Client {
 		fmt.Printf("Unable to parse node address: %#v\n", conf)
 		os.Exit(1)
 	}
-	return *blockstack.NewClient(conf)
+	return blockstack.NewClient(conf)
 }
 
 // handleResult prints results from the RPC calls
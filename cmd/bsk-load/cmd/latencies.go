@@ -120,7 +120,7 @@ func (l ls) mean() time.Duration {
 	return time.Duration(int(out) / len(l))
 }
 
-func (l latencies) byCallSummary() string {
+func (l *latencies) byCallSummary() string {
 	ret := make(byCall, 0)
 	for _, lat := range l.l {
 		if _, ok := ret[lat.call]; ok {
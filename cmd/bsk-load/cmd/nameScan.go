@@ -33,7 +33,7 @@ var nameScanCmd = &cobra.Command{
 	Use:   "nameScan",
 	Short: "Scan all names from all namespaces",
 	Run: func(cmd *cobra.Command, args []string) {
-		ns := newNameScan(cfg.TestServer)
+		ns := newNameScan(cfg)
 		go ns.report()
 		ns.runNameScan()
 	},
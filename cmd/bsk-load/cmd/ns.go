@@ -22,9 +22,13 @@ import (
 	"github.com/blockstack/blockstack.go/blockstack"
 )
 
-func newNameScan(cfg blockstack.ServerConfig) nameScan {
+func newNameScan(cfg *Config) nameScan {
+	var clients []*blockstack.Client
+	for _, conf := range cfg.servers() {
+		clients = append(clients, blockstack.NewClient(conf))
+	}
 	ns := nameScan{
-		c:          blockstack.NewClient(cfg),
+		pool:       blockstack.NewPool(cfg.Pool, clients...),
 		namespaces: make([]namespace, 0),
 		l:          newLatencies(),
 		lchan:      make(chan *latency, 0),
@@ -34,7 +38,7 @@ func newNameScan(cfg blockstack.ServerConfig) nameScan {
 }
 
 type nameScan struct {
-	c          *blockstack.Client
+	pool       *blockstack.Pool
 	namespaces []namespace
 	l          *latencies
 
@@ -68,7 +72,7 @@ func (ns nameScan) handleLatencies() {
 func (ns nameScan) gans() blockstack.GetAllNamespacesResult {
 	call := "get_all_namespaces"
 	l := newLatency(call)
-	res, err := ns.c.GetAllNamespaces()
+	res, err := ns.pool.Next().GetAllNamespaces()
 	check(err)
 	l.endTime = time.Now()
 	ns.lchan <- l
@@ -78,7 +82,7 @@ func (ns nameScan) gans() blockstack.GetAllNamespacesResult {
 func (ns nameScan) gnnin(namespace string) blockstack.CountResult {
 	call := "get_num_names_in_namespace"
 	l := newLatency(call)
-	res, err := ns.c.GetNumNamesInNamespace(namespace)
+	res, err := ns.pool.Next().GetNumNamesInNamespace(namespace)
 	check(err)
 	l.endTime = time.Now()
 	ns.lchan <- l
@@ -88,7 +92,7 @@ func (ns nameScan) gnnin(namespace string) blockstack.CountResult {
 func (ns nameScan) gnin(namespace string, page int, namePageSize int) blockstack.GetNamesInNamespaceResult {
 	call := "get_names_in_namespace"
 	l := newLatency(call)
-	res, err := ns.c.GetNamesInNamespace(namespace, page*namePageSize, namePageSize)
+	res, err := ns.pool.Next().GetNamesInNamespace(namespace, page*namePageSize, namePageSize)
 	check(err)
 	l.endTime = time.Now()
 	ns.lchan <- l
@@ -98,7 +102,7 @@ func (ns nameScan) gnin(namespace string, page int, namePageSize int) blockstack
 func (ns nameScan) gnbr(name string) blockstack.GetNameBlockchainRecordResult {
 	call := "get_blockchain_name_record"
 	l := newLatency(call)
-	res, err := ns.c.GetNameBlockchainRecord(name)
+	res, err := ns.pool.Next().GetNameBlockchainRecord(name)
 	check(err)
 	l.endTime = time.Now()
 	ns.lchan <- l
@@ -108,7 +112,7 @@ func (ns nameScan) gnbr(name string) blockstack.GetNameBlockchainRecordResult {
 func (ns nameScan) gz(zonefiles []string) blockstack.GetZonefilesResult {
 	call := "get_zonefiles"
 	l := newLatency(call)
-	res, err := ns.c.GetZonefiles(zonefiles)
+	res, err := ns.pool.Next().GetZonefiles(zonefiles)
 	check(err)
 	l.endTime = time.Now()
 	ns.lchan <- l
@@ -121,6 +125,9 @@ func (ns nameScan) report() {
 		ns.l.Lock()
 		fmt.Println(ns.l.byCallSummary())
 		ns.l.Unlock()
+		for _, node := range ns.pool.Stats() {
+			fmt.Println(node.JSON())
+		}
 	}
 }
 
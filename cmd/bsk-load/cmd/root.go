@@ -26,7 +26,18 @@ import (
 
 // Config represents the config file
 type Config struct {
-	TestServer blockstack.ServerConfig `json:"testServer"`
+	TestServer  blockstack.ServerConfig   `json:"testServer"`
+	TestServers []blockstack.ServerConfig `json:"testServers"`
+	Pool        blockstack.PoolConfig     `json:"pool"`
+}
+
+// servers returns TestServer followed by TestServers
+func (c *Config) servers() []blockstack.ServerConfig {
+	var out []blockstack.ServerConfig
+	if c.TestServer.Address != "" {
+		out = append(out, c.TestServer)
+	}
+	return append(out, c.TestServers...)
 }
 
 var cfgFile string
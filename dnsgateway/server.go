@@ -0,0 +1,399 @@
+// Package dnsgateway runs an authoritative DNS server for Blockstack
+// namespaces. A query for muneeb.id is answered from the records in the
+// zonefile of muneeb.id, found through blockstack-core or an indexer Store.
+package dnsgateway
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+	"github.com/blockstack/blockstack.go/indexer"
+	"github.com/miekg/dns"
+)
+
+const (
+	logPrefix = "[dns]"
+
+	defaultTTL          = 3600
+	defaultPollInterval = 30 * time.Second
+	defaultCacheSize    = 10000
+	lookupTimeout       = 10 * time.Second
+)
+
+// servedTypes are the record types answered from zonefiles
+var servedTypes = map[uint16]bool{
+	dns.TypeA:     true,
+	dns.TypeAAAA:  true,
+	dns.TypeTXT:   true,
+	dns.TypeURI:   true,
+	dns.TypeCNAME: true,
+}
+
+// Config configures a Server
+type Config struct {
+	// Addr is the address the UDP and TCP servers listen on
+	Addr string
+	// Namespaces are the zones the server is authoritative for, e.g. id
+	Namespaces []string
+	// URLs of the blockstack-core nodes, only the nodes in consensus are used
+	URLs []string
+	Pool blockstack.PoolConfig
+	// ClientUpdateInterval is how often to check which nodes are in consensus
+	ClientUpdateInterval time.Duration
+	// Store, when set, is checked for names before blockstack-core
+	Store indexer.Store
+	// PollInterval is how often to check for a new block, which empties the cache
+	PollInterval time.Duration
+	// CacheSize is the most names kept in the cache
+	CacheSize int
+}
+
+// Server answers DNS queries for Blockstack names
+type Server struct {
+	Pool *blockstack.Pool
+
+	config    Config
+	cache     map[string]*indexer.Domain
+	lastBlock int
+	servers   []*dns.Server
+	stop      chan struct{}
+
+	sync.Mutex
+}
+
+// NewServer returns a *Server using the nodes in conf.URLs that are in consensus
+func NewServer(conf Config) (*Server, error) {
+	s := newServer(conf, blockstack.NewPool(conf.Pool))
+	s.setClients()
+	if s.Pool.Len() == 0 {
+		return nil, fmt.Errorf("no blockstack-core nodes in consensus")
+	}
+	return s, nil
+}
+
+// newServer returns a *Server using pool
+func newServer(conf Config, pool *blockstack.Pool) *Server {
+	return &Server{
+		Pool:   pool,
+		config: conf,
+		cache:  make(map[string]*indexer.Domain),
+		stop:   make(chan struct{}),
+	}
+}
+
+// ListenAndServe serves DNS over UDP and TCP on Config.Addr until Shutdown is called
+func (s *Server) ListenAndServe() error {
+	s.Lock()
+	for _, proto := range []string{"udp", "tcp"} {
+		s.servers = append(s.servers, &dns.Server{Addr: s.config.Addr, Net: proto, Handler: s})
+	}
+	servers := s.servers
+	s.Unlock()
+
+	go s.runBlockPoller()
+	if s.config.ClientUpdateInterval > 0 {
+		go s.runClientUpdater()
+	}
+	errs := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func(srv *dns.Server) {
+			errs <- srv.ListenAndServe()
+		}(srv)
+	}
+	return <-errs
+}
+
+// Shutdown stops the servers started by ListenAndServe
+func (s *Server) Shutdown() {
+	s.Lock()
+	defer s.Unlock()
+	close(s.stop)
+	for _, srv := range s.servers {
+		srv.Shutdown()
+	}
+}
+
+// setClients points the pool at the nodes in conf.URLs that are in consensus
+func (s *Server) setClients() {
+	clients, errs := blockstack.ValidClients(s.config.URLs)
+	for _, err := range errs {
+		if er, ok := err.(blockstack.ClientRegistrationError); ok {
+			log.Println(logPrefix, er.URL, er.Err)
+		}
+	}
+	if len(clients) == 0 {
+		log.Println(logPrefix, "No blockstack-core nodes in consensus, keeping the current ones")
+		return
+	}
+	s.Pool.Set(clients)
+}
+
+// runClientUpdater checks which nodes are in consensus every ClientUpdateInterval
+func (s *Server) runClientUpdater() {
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(s.config.ClientUpdateInterval):
+		}
+		log.Println(logPrefix, "Updating blockstack-core clients...")
+		s.setClients()
+	}
+}
+
+// runBlockPoller empties the cache whenever blockstack-core processes a new block
+func (s *Server) runBlockPoller() {
+	interval := s.config.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(interval):
+		}
+		info, err := s.Pool.Next().GetInfo()
+		if err != nil {
+			log.Println(logPrefix, "Error checking for new blocks:", err)
+			continue
+		}
+		s.setBlock(info.LastBlockProcessed)
+	}
+}
+
+// setBlock records the chain tip and empties the cache if it moved
+func (s *Server) setBlock(block int) {
+	s.Lock()
+	defer s.Unlock()
+	if block != s.lastBlock {
+		s.lastBlock = block
+		s.cache = make(map[string]*indexer.Domain)
+	}
+}
+
+// ServeDNS satisfies dns.Handler
+func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	if err := w.WriteMsg(s.answer(r)); err != nil {
+		log.Println(logPrefix, "Error writing response:", err)
+	}
+}
+
+// answer returns the response to the query r
+func (s *Server) answer(r *dns.Msg) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	if len(r.Question) != 1 {
+		m.Rcode = dns.RcodeFormatError
+		return m
+	}
+	q := r.Question[0]
+	qname := strings.ToLower(dns.Fqdn(q.Name))
+	zone := s.zone(qname)
+	if zone == "" {
+		m.Rcode = dns.RcodeRefused
+		return m
+	}
+	m.Authoritative = true
+
+	d, err := s.domain(qname, zone)
+	if err != nil {
+		log.Println(logPrefix, "Error looking up", qname, err)
+		m.Rcode = dns.RcodeServerFailure
+		return m
+	}
+	if d == nil {
+		m.Rcode = dns.RcodeNameError
+		m.Ns = []dns.RR{s.soa(zone)}
+		return m
+	}
+	m.Answer = records(d, qname, q.Qtype)
+	if len(m.Answer) == 0 {
+		m.Ns = []dns.RR{s.soa(zone)}
+	}
+	return m
+}
+
+// zone returns the namespace qname is in, "" if it is not served
+func (s *Server) zone(qname string) string {
+	for _, ns := range s.config.Namespaces {
+		zone := dns.Fqdn(strings.ToLower(ns))
+		if dns.IsSubDomain(zone, qname) && qname != zone {
+			return zone
+		}
+	}
+	return ""
+}
+
+// domain returns the name holding the records of qname, nil if there is none.
+// Names below a registered name are looked up in its subdomains first, then
+// in its own zonefile
+func (s *Server) domain(qname, zone string) (*indexer.Domain, error) {
+	labels := dns.SplitDomainName(qname)
+	zoneLabels := dns.CountLabel(zone)
+	d, err := s.lookup(strings.Join(labels[len(labels)-zoneLabels-1:], "."))
+	if err != nil || d == nil || len(labels) == zoneLabels+1 {
+		return d, err
+	}
+	for _, sd := range d.Subdomains() {
+		if sd.Name == strings.TrimSuffix(qname, ".") || hasName(sd, qname) {
+			return sd, nil
+		}
+	}
+	if hasName(d, qname) {
+		return d, nil
+	}
+	return nil, nil
+}
+
+// lookup returns the resolvable domain for name from the cache, the Store or
+// blockstack-core. Names that are not registered, expired or revoked are nil
+// and are not cached so queries for made up names can not fill the cache
+func (s *Server) lookup(name string) (*indexer.Domain, error) {
+	s.Lock()
+	d, ok := s.cache[name]
+	s.Unlock()
+	if ok {
+		return d, nil
+	}
+
+	d, err := s.fetch(name)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, nil
+	}
+	switch blockstack.NameStatus(d.BlockchainRecord) {
+	case blockstack.StatusRegistered, blockstack.StatusInGracePeriod:
+	default:
+		return nil, nil
+	}
+	s.cacheDomain(name, d)
+	return d, nil
+}
+
+// cacheDomain adds d to the cache, evicting another name when it is full
+func (s *Server) cacheDomain(name string, d *indexer.Domain) {
+	size := s.config.CacheSize
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.cache[name]; !ok && len(s.cache) >= size {
+		for evict := range s.cache {
+			delete(s.cache, evict)
+			break
+		}
+	}
+	s.cache[name] = d
+}
+
+// fetch returns the domain for name with its zonefile, nil if it does not exist.
+// Expiring is not a name operation so a stored name is not written again when
+// it expires, its record is looked at from the chain tip instead. Until the
+// tip is known names are fetched from blockstack-core
+func (s *Server) fetch(name string) (*indexer.Domain, error) {
+	s.Lock()
+	tip := s.lastBlock
+	s.Unlock()
+	if s.config.Store != nil && tip > 0 {
+		d, err := s.config.Store.GetDomain(name)
+		if err == nil && d.BlockchainRecord.Status && d.ZonefileVerified() {
+			if tip > d.BlockchainRecord.Lastblock {
+				d.BlockchainRecord.Lastblock = tip
+			}
+			return d, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	rec, err := s.Pool.Next().GetNameBlockchainRecordContext(ctx, name)
+	switch err.(type) {
+	case nil:
+	case blockstack.StatusError:
+		return nil, nil
+	case blockstack.RPCError:
+		if strings.Contains(strings.ToLower(err.Error()), "not found") {
+			return nil, nil
+		}
+		return nil, err
+	default:
+		return nil, err
+	}
+
+	d := indexer.NewDomain(name)
+	d.BlockchainRecord = rec
+	if hash := rec.Record.ValueHash; hash != "" {
+		res, err := s.Pool.Next().GetZonefiles([]string{hash})
+		if err != nil {
+			return nil, err
+		}
+		if zonefile, ok := res.Decode()[hash]; ok {
+			d.AddZonefile(zonefile)
+		}
+	}
+	return d, nil
+}
+
+// soa returns the SOA record of zone, the serial is the last block seen
+func (s *Server) soa(zone string) dns.RR {
+	s.Lock()
+	serial := uint32(s.lastBlock)
+	s.Unlock()
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: zone, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: defaultTTL},
+		Ns:      "ns." + zone,
+		Mbox:    "hostmaster." + zone,
+		Serial:  serial,
+		Refresh: defaultTTL,
+		Retry:   defaultTTL / 4,
+		Expire:  defaultTTL * 24,
+		Minttl:  defaultTTL / 12,
+	}
+}
+
+// records returns the records of d for qname and qtype. A CNAME is returned
+// for any type when qname has one. TTLs come from the record or $TTL
+func records(d *indexer.Domain, qname string, qtype uint16) []dns.RR {
+	if d.Zonefile == nil {
+		return nil
+	}
+	var out, cnames []dns.RR
+	for _, rr := range d.Zonefile.RRs {
+		h := rr.Header()
+		if !strings.EqualFold(h.Name, qname) || !servedTypes[h.Rrtype] {
+			continue
+		}
+		switch {
+		case h.Rrtype == qtype || qtype == dns.TypeANY:
+			out = append(out, rr)
+		case h.Rrtype == dns.TypeCNAME:
+			cnames = append(cnames, rr)
+		}
+	}
+	if len(out) == 0 {
+		return cnames
+	}
+	return out
+}
+
+// hasName reports whether the zonefile of d has records for qname
+func hasName(d *indexer.Domain, qname string) bool {
+	if d.Zonefile == nil {
+		return false
+	}
+	for _, rr := range d.Zonefile.RRs {
+		if strings.EqualFold(rr.Header().Name, qname) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package dnsgateway
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+	"github.com/blockstack/blockstack.go/blockstack/blockstacktest"
+	"github.com/blockstack/blockstack.go/indexer"
+	"github.com/miekg/dns"
+)
+
+const testZonefile = `$ORIGIN www.id
+$TTL 600
+@ IN A 192.0.2.1
+@ IN AAAA 2001:db8::1
+@ IN TXT "hello world"
+_http._tcp IN URI 10 1 "https://gaia.blockstack.org/hub/1J3PUxY5uDShUnHRrMyU6yKtoHEUPhKULs/0/profile.json"
+blog 300 IN CNAME www.example.com.
+`
+
+const subZonefile = `$ORIGIN alice.www.id
+$TTL 120
+@ IN A 192.0.2.2
+`
+
+// testServer returns a *Server answering for id from core, with www.id registered
+func testServer(core *blockstacktest.Core) *Server {
+	enc := base64.StdEncoding.EncodeToString([]byte(subZonefile))
+	zf := testZonefile + fmt.Sprintf("alice TXT \"owner=1AYddAnfHbw6bPNvnsQFFrEuUdhMhf2XG9\" \"seqn=0\" \"parts=1\" \"zf0=%s\"\n", enc)
+	core.AddName("www.id", "1J3PUxY5uDShUnHRrMyU6yKtoHEUPhKULs", 470000)
+	core.UpdateZonefile("www.id", 470100, zf)
+
+	conf := Config{Namespaces: []string{"id"}}
+	return newServer(conf, blockstack.NewPool(conf.Pool, core.Client()))
+}
+
+// query asks s for name and qtype
+func query(s *Server, name string, qtype uint16) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetQuestion(name, qtype)
+	return s.answer(m)
+}
+
+// TestAnswer tests that records are served from the zonefile with the TTLs it sets
+func TestAnswer(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	s := testServer(core)
+
+	var tests = []struct {
+		name  string
+		qtype uint16
+		rcode int
+		want  string
+		ttl   uint32
+	}{
+		{"www.id.", dns.TypeA, dns.RcodeSuccess, "192.0.2.1", 600},
+		{"WWW.id.", dns.TypeAAAA, dns.RcodeSuccess, "2001:db8::1", 600},
+		{"www.id.", dns.TypeTXT, dns.RcodeSuccess, "hello world", 600},
+		{"_http._tcp.www.id.", dns.TypeURI, dns.RcodeSuccess, "profile.json", 600},
+		{"blog.www.id.", dns.TypeA, dns.RcodeSuccess, "www.example.com.", 300},
+		{"alice.www.id.", dns.TypeA, dns.RcodeSuccess, "192.0.2.2", 120},
+		{"www.id.", dns.TypeMX, dns.RcodeSuccess, "", 0},
+		{"nobody.www.id.", dns.TypeA, dns.RcodeNameError, "", 0},
+		{"notregistered.id.", dns.TypeA, dns.RcodeNameError, "", 0},
+		{"expired.id.", dns.TypeA, dns.RcodeNameError, "", 0},
+		{"www.example.com.", dns.TypeA, dns.RcodeRefused, "", 0},
+	}
+	for _, tt := range tests {
+		res := query(s, tt.name, tt.qtype)
+		if res.Rcode != tt.rcode {
+			t.Errorf("%s %s: rcode %s, expected %s", tt.name, dns.TypeToString[tt.qtype], dns.RcodeToString[res.Rcode], dns.RcodeToString[tt.rcode])
+			continue
+		}
+		if tt.rcode == dns.RcodeRefused {
+			continue
+		}
+		if !res.Authoritative {
+			t.Errorf("%s: expected an authoritative answer", tt.name)
+		}
+		if tt.want == "" {
+			if len(res.Answer) != 0 || len(res.Ns) != 1 || res.Ns[0].Header().Rrtype != dns.TypeSOA {
+				t.Errorf("%s %s: expected no answer and an SOA, got %v %v", tt.name, dns.TypeToString[tt.qtype], res.Answer, res.Ns)
+			}
+			continue
+		}
+		if len(res.Answer) != 1 {
+			t.Errorf("%s %s: expected 1 answer, got %v", tt.name, dns.TypeToString[tt.qtype], res.Answer)
+			continue
+		}
+		if got := res.Answer[0].String(); !strings.Contains(got, tt.want) || res.Answer[0].Header().Ttl != tt.ttl {
+			t.Errorf("%s %s: got %s, expected %s with TTL %d", tt.name, dns.TypeToString[tt.qtype], got, tt.want, tt.ttl)
+		}
+	}
+}
+
+// TestCacheInvalidation tests that answers are cached until a new block is seen
+func TestCacheInvalidation(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	s := testServer(core)
+	s.setBlock(blockstacktest.FixtureLastBlock)
+
+	query(s, "www.id.", dns.TypeA)
+	query(s, "www.id.", dns.TypeA)
+	if n := core.Calls("get_name_blockchain_record"); n != 1 {
+		t.Errorf("expected 1 lookup of a cached name, got %d", n)
+	}
+
+	// Names that do not resolve are not cached
+	query(s, "notregistered.id.", dns.TypeA)
+	query(s, "notregistered.id.", dns.TypeA)
+	if n := core.Calls("get_name_blockchain_record"); n != 3 {
+		t.Errorf("expected missing names to be looked up each time, got %d lookups", n)
+	}
+
+	// The cache is capped
+	s.config.CacheSize = 1
+	query(s, "muneeb.id.", dns.TypeA)
+	if _, ok := s.cache["muneeb.id"]; !ok || len(s.cache) != 1 {
+		t.Errorf("expected only muneeb.id to be cached, got %d names", len(s.cache))
+	}
+
+	core.UpdateZonefile("www.id", blockstacktest.FixtureLastBlock, "$ORIGIN www.id\n$TTL 600\n@ IN A 192.0.2.9\n")
+	s.setBlock(blockstacktest.FixtureLastBlock + 1)
+	res := query(s, "www.id.", dns.TypeA)
+	if len(res.Answer) != 1 || !strings.Contains(res.Answer[0].String(), "192.0.2.9") {
+		t.Errorf("expected the new zonefile to be served after a new block, got %v", res.Answer)
+	}
+}
+
+// TestServerFailure tests that an unreachable blockstack-core is answered with SERVFAIL
+func TestServerFailure(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	s := testServer(core)
+	core.SetUnavailable(true)
+
+	if res := query(s, "www.id.", dns.TypeA); res.Rcode != dns.RcodeServerFailure {
+		t.Errorf("expected SERVFAIL, got %s", dns.RcodeToString[res.Rcode])
+	}
+}
+
+// TestStoredExpiry tests that names from the Store are judged at the chain tip
+// rather than at the block they were indexed at
+func TestStoredExpiry(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	s := testServer(core)
+	s.config.Store = indexer.NewMemoryStore()
+
+	zonefile := "$ORIGIN stored.id\n$TTL 600\n@ IN A 192.0.2.3\n"
+	d := indexer.NewDomain("stored.id")
+	d.BlockchainRecord.Status = true
+	d.BlockchainRecord.Lastblock = blockstacktest.FixtureLastBlock
+	d.BlockchainRecord.Record.ExpireBlock = blockstacktest.FixtureLastBlock + 10
+	d.BlockchainRecord.Record.ValueHash = blockstack.ZonefileHash(zonefile)
+	d.AddZonefile(zonefile)
+	if failed := s.config.Store.UpsertDomains(indexer.Domains{d}); len(failed) > 0 {
+		t.Fatal(failed)
+	}
+
+	// Until the tip is known the name is looked up in blockstack-core
+	if res := query(s, "stored.id.", dns.TypeA); res.Rcode != dns.RcodeNameError {
+		t.Errorf("expected stored.id to be looked up in core before the tip is known, got %s", dns.RcodeToString[res.Rcode])
+	}
+
+	s.setBlock(blockstacktest.FixtureLastBlock + 9)
+	calls := core.Calls("get_name_blockchain_record")
+	if res := query(s, "stored.id.", dns.TypeA); res.Rcode != dns.RcodeSuccess || len(res.Answer) != 1 {
+		t.Errorf("expected stored.id to be served from the Store, got %s %v", dns.RcodeToString[res.Rcode], res.Answer)
+	}
+	if n := core.Calls("get_name_blockchain_record"); n != calls {
+		t.Errorf("expected no lookup in core, got %d", n-calls)
+	}
+
+	s.setBlock(blockstacktest.FixtureLastBlock + 10)
+	if res := query(s, "stored.id.", dns.TypeA); res.Rcode != dns.RcodeNameError {
+		t.Errorf("expected stored.id to have expired at the tip, got %s %v", dns.RcodeToString[res.Rcode], res.Answer)
+	}
+}
@@ -2,11 +2,6 @@ package indexer
 
 import (
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"log"
-	"net/http"
-	"strings"
 	"time"
 
 	"github.com/blockstack/blockstack.go/blockstack"
@@ -28,6 +23,16 @@ type Domain struct {
 	Zonefile         *Zonefile                                `json:"zonefile"`
 	Profile          Profile                                  `json:"profile"`
 	BlockchainRecord blockstack.GetNameBlockchainRecordResult `json:"blockchainRecord"`
+	// Status is the blockstack.NameStatus of BlockchainRecord when it was indexed
+	Status string `json:"status"`
+	// Subdomain is set for names registered in the zonefile of their parent
+	Subdomain *Subdomain `json:"subdomain,omitempty"`
+	// Verification is the outcome of the last check of Profile against the owner
+	Verification *ProfileVerification `json:"verification,omitempty"`
+	// Proofs are the outcomes of checking the social proofs in Profile
+	Proofs []ProofVerification `json:"proofs,omitempty"`
+	// ProfileError is why the profile could not be fetched on the last try
+	ProfileError *ProfileError `json:"profileError,omitempty"`
 
 	lastResolved time.Time
 }
@@ -39,6 +44,11 @@ func (d *Domain) zonefileHash() string {
 	return ""
 }
 
+// ZonefileVerified reports whether d's zonefile hashes to the value_hash in its record
+func (d *Domain) ZonefileVerified() bool {
+	return d.Zonefile != nil && d.zonefileHash() != "" && blockstack.ZonefileHash(d.Zonefile.Raw) == d.zonefileHash()
+}
+
 // JSON returns the JSON representation of Domain
 func (d *Domain) JSON() string {
 	byt, err := json.Marshal(d)
@@ -60,80 +70,38 @@ func (d *Domain) GetURI() *dns.URI {
 	return URI
 }
 
-// AddZonefile takes a string representation of a Zonefile and parses out some info
+// AddZonefile takes a string representation of a Zonefile and parses out some info.
+// A zonefile that is not a zone file may be a legacy profile
 func (d *Domain) AddZonefile(zonefile string) {
-	d.Zonefile = &Zonefile{
-		Raw:       zonefile,
-		RRs:       make([]dns.RR, 0),
-		Compliant: true,
-	}
-	for x := range dns.ParseZone(strings.NewReader(zonefile), "", "") {
-		if x.Error != nil {
-			d.Zonefile.Compliant = false
-			var legacyProfile LegacyProfile
-			// NOTE: Squash error here. We don't care about it
-			json.Unmarshal([]byte(zonefile), &legacyProfile)
-			if legacyProfile.Account == nil {
-				d.Profile = nil
-			} else {
-				d.Profile = legacyProfile
-			}
+	d.Zonefile = newZonefile(zonefile)
+	if !d.Zonefile.Compliant {
+		var legacyProfile LegacyProfile
+		// NOTE: Squash error here. We don't care about it
+		json.Unmarshal([]byte(zonefile), &legacyProfile)
+		if legacyProfile.Account == nil {
+			d.Profile = nil
 		} else {
-			// TODO: Handle fetching subdomains here...
-			// Might need to lock the []*Domain in a bunch of places
-			// if x.RR.Header().Rrtype == 16 {
-			// 	fmt.Println(x.RR)
-			// }
-			// created_equal.self_evident_truth.id.	3600	IN	TXT	"owner=1AYddAnfHbw6bPNvnsQFFrEuUdhMhf2XG9" "seqn=0" "parts=1" "zf0=JE9SSUdJTiBjcmVhdGVkX2VxdWFsCiRUVEwgMzYwMApfaHR0cHMuX3RjcCBVUkkgMTAgMSAiaHR0cHM6Ly93d3cuY3MucHJpbmNldG9uLmVkdS9+YWJsYW5rc3QvY3JlYXRlZF9lcXVhbC5qc29uIgpfZmlsZSBVUkkgMTAgMSAiZmlsZTovLy90bXAvY3JlYXRlZF9lcXVhbC5qc29uIgo="
-			d.Zonefile.RRs = append(d.Zonefile.RRs, x.RR)
+			d.Profile = legacyProfile
 		}
 	}
 }
 
-// ResolveProfile takes an initialized domain and fetches the resulting profile for that domain
-// TODO: Make this fail early and often to prevent bottleneck also this basically doesn't work
-func (d *Domain) ResolveProfile() {
-	// fmt.Println(d.Name)
-	URI := d.GetURI()
-	// Check that a URI was returned and there is a Target
-	// Also check that the profile was not in the legacy format
-	if URI != nil && d.Profile != nil {
-		target := URI.Target
-
-		// Handle dropbox urls with no http prefix
-		if strings.TrimPrefix(target, "www") != target {
-			target = fmt.Sprintf("http://%s", target)
-		}
-		res, err := http.Get(target)
-		if err != nil || res.StatusCode == 404 {
-			log.Printf("Error fetching profile for %v: %v\n", d.Name, err)
-			d.lastResolved = time.Now()
-			return
-		}
-		body, err := ioutil.ReadAll(res.Body)
-		if err != nil {
-			log.Printf("Error reading body for %v: %v", d.Name, err)
-		}
-		var p1 []SOProfile
-		err = json.Unmarshal(body, &p1)
-		if err != nil && strings.Contains(err.Error(), "cannot unmarshal object") {
-			var p2 SOProfile
-			json.Unmarshal(body, &p2)
-			if err != nil {
-				fmt.Printf("Error unmarshalling %v\nERROR: %v\nRES: %v\nTARGET: %v\n\n", d.Name, err, string(body), target)
-			}
-			d.Profile = &p2
-		} else {
-			if len(p1) > 0 {
-				d.Profile = &p1[0]
-			}
-		}
-		if d.Profile != nil {
-			fmt.Println(d.Profile.JSON())
-		}
-		res.Body.Close()
-	}
+// ResolveProfile fetches the profile from the URI records of d's zonefile.
+// Legacy profiles are kept in the zonefile itself so they are not fetched
+func (d *Domain) ResolveProfile(pf *ProfileFetcher) {
 	d.lastResolved = time.Now()
+	if d.Zonefile == nil {
+		return
+	}
+	if _, legacy := d.Profile.(LegacyProfile); legacy {
+		return
+	}
+	p, err := pf.Fetch(d.Zonefile)
+	if err != nil {
+		d.ProfileError = err
+		return
+	}
+	d.Profile, d.ProfileError = p, nil
 }
 
 // Domains is a collection of *Domain
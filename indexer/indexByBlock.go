@@ -0,0 +1,91 @@
+package indexer
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// startByBlock lists every name with its blockchain record up front and then
+// sends them down the same pipeline as byName to fetch zonefiles and resolve
+// profiles. The zonefile of each name is the one its record points at, names
+// changed after the tip are picked up when following new blocks.
+// It returns the block the names were listed at once every page has been sent
+func (i *Indexer) startByBlock() int {
+	start := time.Now()
+
+	ns, err := i.GetAllNamespaces()
+	if err != nil {
+		if i.stopping() {
+			return 0
+		}
+		log.Fatalln(logPrefix, "Unable to fetch namespaces:", err)
+	}
+	tip := ns.Lastblock
+	go i.setCB(tip)
+
+	domains := i.listNames(ns.Namespaces)
+	log.Printf("%s Listed %d names in %v, resolving...", logPrefix, len(domains), time.Since(start))
+
+	// The name page workers fetch the zonefiles for each page in one call
+	page := make(Domains, 0, namePageSize)
+	for _, dom := range domains {
+		page = append(page, dom)
+		if len(page) == namePageSize {
+			i.sendPage(page)
+			page = make(Domains, 0, namePageSize)
+		}
+	}
+	i.sendPage(page)
+	return tip
+}
+
+// listNames returns a *Domain with its blockchain record for every name in
+// the namespaces. Names whose record can not be fetched are left out
+func (i *Indexer) listNames(namespaces []string) map[string]*Domain {
+	domains := make(map[string]*Domain)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, i.Config.ConcurrentPageFetch)
+
+	for _, ns := range namespaces {
+		numNames, err := i.GetNumNamesInNamespace(ns)
+		if err != nil {
+			log.Println(logPrefix, "Skipping namespace", ns, err)
+			continue
+		}
+		pages := (numNames.Count + namePageSize - 1) / namePageSize
+		for page := 0; page < pages && !i.stopping(); page++ {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(ns string, page int) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				namePage, err := i.GetNamesInNamespace(ns, page*namePageSize, namePageSize)
+				if err != nil {
+					log.Println(logPrefix, "Skipping page", page, "of", ns, err)
+					return
+				}
+				for _, name := range namePage.Names {
+					res, err := i.GetNameBlockchainRecord(name)
+					if err != nil {
+						log.Println(logPrefix, "Error fetching name details for", name, err)
+						i.summary.fail()
+						continue
+					}
+					dom := NewDomain(name)
+					dom.BlockchainRecord = res
+					mu.Lock()
+					domains[name] = dom
+					mu.Unlock()
+					i.stats.nameDetailsFetched.Inc()
+				}
+				i.stats.namePagesFetched.Inc()
+			}(ns, page)
+		}
+	}
+	wg.Wait()
+	return domains
+}
@@ -0,0 +1,54 @@
+package indexer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blockstack/blockstack.go/blockstack/blockstacktest"
+)
+
+// TestListNames tests that every name in every namespace is listed
+func TestListNames(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+
+	domains := testIndexer(core).listNames([]string{"id", "helloworld"})
+	if len(domains) != len(core.Records) {
+		t.Fail()
+	}
+	for name, rec := range core.Records {
+		if domains[name] == nil || domains[name].BlockchainRecord.Record.Address != rec.Record.Address {
+			t.Errorf("%s: expected the blockchain record owned by %s", name, rec.Record.Address)
+		}
+	}
+}
+
+// TestStartByBlockUsesRecord tests that the zonefile of a name is the one its
+// record points at, not an older one announced in the chain
+func TestStartByBlockUsesRecord(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	core.Lock()
+	rec := core.Records["muneeb.id"]
+	rec.Record.ValueHash = ""
+	core.Records["muneeb.id"] = rec
+	core.Unlock()
+
+	idx := testIndexer(core)
+	idx.Config.IndexMethod = "byBlock"
+	idx.Config.ClientUpdateInterval = 60
+	idx.Config.DBFlushInterval = 10 * time.Millisecond
+	idx.Config.NamePageWorkers, idx.Config.ResolveWorkers, idx.Config.DBWorkers = 2, 2, 2
+	idx.Start()
+
+	d, err := idx.Store().GetDomain("muneeb.id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.BlockchainRecord.Record.ValueHash != "" || d.Zonefile != nil {
+		t.Errorf("expected muneeb.id without a zonefile, got hash %q", d.BlockchainRecord.Record.ValueHash)
+	}
+	if core.Calls("get_zonefiles_by_block") != 0 {
+		t.Errorf("expected the chain not to be scanned for zonefiles")
+	}
+}
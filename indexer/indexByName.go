@@ -2,39 +2,56 @@ package indexer
 
 import (
 	"log"
+	"sync"
+	"time"
+
+	"github.com/blockstack/blockstack.go/blockstack"
 )
 
 var (
 	namePageSize = 100
 )
 
-// startByNames retrieves all the names from all namespaces
-func (i *Indexer) startByNames() {
-	i.startWorkers()
-
-	ns, err := i.client().GetAllNamespaces()
+// startByNames retrieves all the names from all namespaces and sends them
+// down the pipeline. It returns the block the names were listed at once every
+// page has been sent
+func (i *Indexer) startByNames() int {
+	ns, err := i.GetAllNamespaces()
 	if err != nil {
-		// TODO: Better error handling here
-		panic(err)
+		if i.stopping() {
+			return 0
+		}
+		log.Fatalln(logPrefix, "Unable to fetch namespaces:", err)
 	}
 
 	go i.setCB(ns.Lastblock)
+	var wg sync.WaitGroup
 	for _, n := range ns.Namespaces {
-		go i.getAllNamePagesInNamespace(n)
+		wg.Add(1)
+		go func(n string) {
+			defer wg.Done()
+			i.getAllNamePagesInNamespace(n)
+		}(n)
 	}
+	wg.Wait()
+	// The pages are fetched in their own goroutines, the names on them
+	// are only pending once they have been sent
+	i.fetchWait.Wait()
+	return ns.Lastblock
 }
 
 // Starts all the worker routines
 func (i *Indexer) startWorkers() {
-	go i.startNamePageWorkers()
-	go i.startResolveWorkers()
-	go i.startDBWorkers()
+	i.startNamePageWorkers()
+	i.startResolveWorkers()
+	i.startDBWorkers()
 }
 
 // startNamePageWorkers kicks off i.Config.NamePageWorkers workers
 // to handle the GetNamesInNamespace returns and Zonefile fetching
 func (i *Indexer) startNamePageWorkers() {
 	for iter := 0; iter < i.Config.NamePageWorkers; iter++ {
+		i.namePageWait.Add(1)
 		go i.handleNamePageChan()
 	}
 }
@@ -43,6 +60,7 @@ func (i *Indexer) startNamePageWorkers() {
 // to handle the *Domains that have zonefiles
 func (i *Indexer) startResolveWorkers() {
 	for iter := 0; iter < i.Config.ResolveWorkers; iter++ {
+		i.resolveWait.Add(1)
 		go i.handleResolveChan()
 	}
 }
@@ -51,32 +69,38 @@ func (i *Indexer) startResolveWorkers() {
 // to handle batching and insertion/update of the database
 func (i *Indexer) startDBWorkers() {
 	for iter := 0; iter < i.Config.DBWorkers; iter++ {
+		i.dbWait.Add(1)
 		go i.handleDBChan()
 	}
 }
 
 // getAllNamePagesInNamespace gets all the NamePages in a namespace
 func (i *Indexer) getAllNamePagesInNamespace(ns string) {
-	numNames, err := i.client().GetNumNamesInNamespace(ns)
+	numNames, err := i.GetNumNamesInNamespace(ns)
 	if err != nil {
-		// TODO: Better error handling here
-		panic(err)
+		log.Println(logPrefix, "Skipping namespace", ns, err)
+		return
 	}
 
 	iter := (numNames.Count/namePageSize + 1)
 	sem := make(chan struct{}, i.Config.ConcurrentPageFetch)
-	for page := 0; page <= iter; page++ {
+	for page := 0; page <= iter && !i.stopping(); page++ {
 		sem <- struct{}{}
+		i.fetchWait.Add(1)
 		go i.getNamePageAsync(page, ns, sem)
 	}
 }
 
 // A goroutine safe method for fetching the list of names from blockstack-core
 func (i *Indexer) getNamePageAsync(page int, ns string, sem chan struct{}) {
-	namePage, err := i.client().GetNamesInNamespace(ns, page*namePageSize, namePageSize)
+	defer func() {
+		<-sem
+		i.fetchWait.Done()
+	}()
+	namePage, err := i.GetNamesInNamespace(ns, page*namePageSize, namePageSize)
 	if err != nil {
-		// TODO: Better error handling here
-		panic(err)
+		log.Println(logPrefix, "Skipping page", page, "of", ns, err)
+		return
 	}
 
 	go i.setCB(namePage.Lastblock)
@@ -84,18 +108,34 @@ func (i *Indexer) getNamePageAsync(page int, ns string, sem chan struct{}) {
 	var domains []*Domain
 	for _, name := range namePage.Names {
 		dom := NewDomain(name)
-		res, err := i.client().GetNameBlockchainRecord(name)
+		res, err := i.GetNameBlockchainRecord(name)
 		if err != nil {
-			// TODO: Better error handling here
-			log.Println("Error fetching name details", err)
+			log.Println(logPrefix, "Error fetching name details for", name, err)
+			i.summary.fail()
+			continue
 		}
 		dom.BlockchainRecord = res
 		domains = append(domains, dom)
 		i.stats.nameDetailsFetched.Inc()
 	}
 	i.stats.namePagesFetched.Inc()
-	i.namePageChan <- domains
-	<-sem
+	i.sendPage(domains)
+}
+
+// sendPage sends a page of names down the pipeline to have their zonefiles
+// fetched, profiles resolved and be written to the database. The page is
+// dropped if the Indexer is stopping
+func (i *Indexer) sendPage(domains Domains) {
+	if len(domains) == 0 {
+		return
+	}
+	i.pending.Add(len(domains))
+	select {
+	case i.namePageChan <- domains:
+		i.summary.see(len(domains))
+	case <-i.stop:
+		i.pending.Add(-len(domains))
+	}
 }
 
 // handleNamePageChan handles namePages coming back from blockstack core
@@ -104,59 +144,124 @@ func (i *Indexer) handleNamePageChan() {
 	for doms := range i.namePageChan {
 
 		// Get zonefileHashes from Domains and get zonefiles
-		res, err := i.client().GetZonefiles(doms.getZonefileHashes())
+		res, err := i.GetZonefiles(doms.getZonefileHashes())
 		if err != nil {
-			// TODO: Better error handling here
-			log.Fatal(logPrefix, err)
+			log.Println(logPrefix, "Error fetching zonefiles, resolving page without them", err)
 		}
 
 		go i.setCB(res.Lastblock)
 		i.stats.zonefilesFetched.Add(float64(len(res.Zonefiles)))
 
-		// TODO: Double check behavior here. Make sure this is doing what you think it is
+		// Only zonefiles that hash to their value_hash are returned, names
+		// whose zonefile is missing or did not match are resolved without one
 		zonefiles := res.Decode()
 		for _, dom := range doms {
-			if zonefileHash := dom.zonefileHash(); zonefileHash != "" {
-				dom.AddZonefile(zonefiles[zonefileHash])
+			var subdomains Domains
+			if zonefile, ok := zonefiles[dom.zonefileHash()]; ok {
+				dom.AddZonefile(zonefile)
 				if dom.Profile != nil {
 					i.stats.withProfiles.Inc()
 				}
+				subdomains = dom.NewSubdomains(i.storedSubdomain)
 			}
+			// Subdomains are resolved and written like any other name. They
+			// are counted as pending before the parent can be written so the
+			// pipeline is not seen as drained while they are still to come
+			i.pending.Add(len(subdomains))
+			i.summary.see(len(subdomains))
+			i.stats.subdomainsFound.Add(float64(len(subdomains)))
 			i.resolveChan <- dom
 			i.stats.sentDownResolveChan.Inc()
+			for _, sub := range subdomains {
+				i.resolveChan <- sub
+				i.stats.sentDownResolveChan.Inc()
+			}
 		}
 
-		// TODO: find a way to close this chan so that the waitgroup finishes
 	}
-	// TODO: Double check these WaitGroups
-	// Once the for loop exits decrement the WaitGroup
+	// The channel is closed once the pipeline is drained
 	i.namePageWait.Done()
 }
 
+// storedSubdomain returns the subdomain name as it is in the Store, nil if
+// it is not there
+func (i *Indexer) storedSubdomain(name string) *Subdomain {
+	cur, err := i.store.GetDomain(name)
+	if err != nil {
+		return nil
+	}
+	return cur.Subdomain
+}
+
 // handleResolveChan handles *Domain after they have zonefiles
 func (i *Indexer) handleResolveChan() {
 	for d := range i.resolveChan {
-		if d.Profile != nil {
-			d.ResolveProfile()
+		if _, legacy := d.Profile.(LegacyProfile); !legacy && d.Zonefile != nil {
+			d.ResolveProfile(i.profiles)
 			if d.Profile != nil {
 				i.stats.withProfiles.Inc()
+				i.summary.resolve()
 			}
 		}
+		d.Status = blockstack.NameStatus(d.BlockchainRecord)
+		d.VerifyProfile()
+		if i.Config.CheckProofs {
+			d.CheckProofs(i.proofs)
+		}
 		i.dbChan <- d
 		i.stats.namesResolved.Inc()
 	}
+	i.resolveWait.Done()
 }
 
-// handleDBChan batches *Domain for insert/update of the MongoDB instance
+// handleDBChan batches *Domain for insert/update of the MongoDB instance.
+// A batch is written once it holds Config.DBBatchSize names or has been
+// waiting for Config.DBFlushInterval, and whatever is left when dbChan closes
 func (i *Indexer) handleDBChan() {
-	for d := range i.dbChan {
-		d.Profile.Validate()
-		session := i.mongoConn.Copy()
-		c := session.DB(mongoDB).C(mongoCollection)
-		err := c.Insert(d)
-		if err != nil {
-			log.Println(logPrefix, "MONGO", err)
+	size := i.Config.dbBatchSize()
+	ticker := time.NewTicker(i.Config.dbFlushInterval())
+	defer ticker.Stop()
+
+	batch := make(Domains, 0, size)
+	for {
+		select {
+		case d, ok := <-i.dbChan:
+			if !ok {
+				i.writeBatch(batch)
+				i.dbWait.Done()
+				return
+			}
+			batch = append(batch, d)
+			if len(batch) >= size {
+				i.writeBatch(batch)
+				batch = make(Domains, 0, size)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				i.writeBatch(batch)
+				batch = make(Domains, 0, size)
+			}
+		}
+	}
+}
+
+// writeBatch upserts a batch of *Domain keyed by name
+func (i *Indexer) writeBatch(batch Domains) {
+	if len(batch) == 0 {
+		return
+	}
+	failed := i.store.UpsertDomains(batch)
+	if len(failed) > 0 {
+		log.Printf("%s %d of %d names in batch failed to write", logPrefix, len(failed), len(batch))
+	}
+	for n, d := range batch {
+		if e, ok := failed[n]; ok {
+			log.Println(logPrefix, "Failed to write", d.Name, e)
+			i.summary.fail()
+		} else {
+			i.stats.writtenToDatabase.Inc()
+			i.summary.write()
 		}
-		session.Close()
+		i.pending.Done()
 	}
 }
@@ -0,0 +1,143 @@
+package indexer
+
+import (
+	"log"
+	"sort"
+	"time"
+)
+
+var (
+	// nameOpsPageSize is the most name operations blockstack-core returns per call
+	nameOpsPageSize = 10
+
+	// zonefileInfoPageSize is the most zonefile_info entries blockstack-core returns per call
+	zonefileInfoPageSize = 100
+)
+
+// lastIndexedBlock returns the block the index was last brought up to, 0 if it never was
+func (i *Indexer) lastIndexedBlock() int {
+	block, err := i.store.LastIndexedBlock()
+	if err != nil {
+		log.Fatalln(logPrefix, "Unable to read indexer state:", err)
+	}
+	return block
+}
+
+// saveLastIndexedBlock records that every name changed up to block is in the index
+func (i *Indexer) saveLastIndexedBlock(block int) {
+	if err := i.store.SetLastIndexedBlock(block); err != nil {
+		log.Println(logPrefix, "Unable to save indexer state", err)
+	}
+	i.setCB(block)
+	i.setIndexed(block)
+}
+
+// follow polls blockstack-core every Config.PollInterval and re-indexes the
+// names changed in any blocks processed since last until the Indexer is stopped.
+// The blocks are only marked as indexed once every changed name is written
+func (i *Indexer) follow(last int) {
+	log.Println(logPrefix, "Following new blocks every", i.Config.PollInterval)
+	for {
+		select {
+		case <-i.stop:
+			return
+		case <-time.After(i.Config.PollInterval):
+		}
+		info, err := i.GetInfo()
+		if err != nil {
+			continue
+		}
+		tip := info.LastBlockProcessed
+		if tip <= last {
+			continue
+		}
+
+		// On failure the same blocks are tried again on the next poll
+		failed := i.Summary().NamesFailed
+		updated, ok := i.indexBlocks(last+1, tip)
+		i.pending.Wait()
+		if !ok || i.stopping() {
+			continue
+		}
+		if n := i.Summary().NamesFailed - failed; n > 0 {
+			log.Printf("%s %d names changed in blocks %d to %d failed to write, retrying", logPrefix, n, last+1, tip)
+			continue
+		}
+		i.saveLastIndexedBlock(tip)
+		log.Printf("%s Indexed blocks %d to %d, %d names updated", logPrefix, last+1, tip, updated)
+		last = tip
+	}
+}
+
+// indexBlocks sends every name changed in the blocks from start to end down
+// the pipeline with its current record. It returns the number of names sent
+// and false if the changed names could not all be found
+func (i *Indexer) indexBlocks(start, end int) (int, bool) {
+	changed := make(map[string]bool)
+	for block := start; block <= end && !i.stopping(); block++ {
+		for offset := 0; ; offset += nameOpsPageSize {
+			res, err := i.GetNameOpsAffectedAt(block, offset, nameOpsPageSize)
+			if err != nil {
+				log.Println(logPrefix, "Unable to fetch name operations at block", block, err)
+				return 0, false
+			}
+			for _, op := range res.Nameops {
+				if op.Name != "" {
+					changed[op.Name] = true
+				}
+			}
+			if len(res.Nameops) < nameOpsPageSize {
+				break
+			}
+		}
+		i.stats.blocksScanned.Inc()
+	}
+
+	// Zonefiles can be announced for a name after the operation that set its hash
+	for offset := 0; ; offset += zonefileInfoPageSize {
+		res, err := i.GetZonefilesByBlock(start, end, offset, zonefileInfoPageSize)
+		if err != nil {
+			log.Println(logPrefix, "Unable to fetch zonefiles for blocks", start, "to", end, err)
+			return 0, false
+		}
+		for _, zfh := range res.ZonefileInfo {
+			changed[zfh.Name] = true
+		}
+		if len(res.ZonefileInfo) < zonefileInfoPageSize {
+			break
+		}
+	}
+
+	names := make([]string, 0, len(changed))
+	for name := range changed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// The names that can be fetched are still sent, all of them are sent
+	// again when the blocks are retried
+	ok := true
+	page := make(Domains, 0, namePageSize)
+	for _, name := range names {
+		if i.stopping() {
+			return 0, false
+		}
+		res, err := i.GetNameBlockchainRecord(name)
+		if err != nil {
+			log.Println(logPrefix, "Error fetching name details for", name, err)
+			i.summary.fail()
+			ok = false
+			continue
+		}
+		dom := NewDomain(name)
+		dom.BlockchainRecord = res
+		page = append(page, dom)
+		i.stats.nameDetailsFetched.Inc()
+		if len(page) == namePageSize {
+			i.sendPage(page)
+			page = make(Domains, 0, namePageSize)
+		}
+	}
+	i.sendPage(page)
+	return len(names), ok
+}
@@ -0,0 +1,154 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blockstack/blockstack.go/blockstack/blockstacktest"
+)
+
+// TestIndexBlocks tests that only the names changed in new blocks are sent down the pipeline
+func TestIndexBlocks(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	core.AddName("newname.id", "1Bv2vJMqBLrm7pRGTsMqDeoKb8bf6fsBPe", blockstacktest.FixtureLastBlock+1)
+	core.UpdateZonefile("newname.id", blockstacktest.FixtureLastBlock+2, blockstacktest.FixtureZonefile("newname.id", "https://example.com/profile.json"))
+	core.Lock()
+	core.Info.LastBlockProcessed = blockstacktest.FixtureLastBlock + 2
+	core.Unlock()
+
+	idx := testIndexer(core)
+	updated, ok := idx.indexBlocks(480001, blockstacktest.FixtureLastBlock+2)
+	if !ok || updated != 4 {
+		t.Fatalf("expected 4 names updated, got %d", updated)
+	}
+
+	page := <-idx.namePageChan
+	expected := []string{"judecn.id", "muneeb.id", "newname.id", "ryan.id"}
+	if len(page) != len(expected) {
+		t.Fatalf("expected a page of %d names, got %d", len(expected), len(page))
+	}
+	for n, dom := range page {
+		if dom.Name != expected[n] || dom.BlockchainRecord.Record.ValueHash != core.Records[dom.Name].Record.ValueHash {
+			t.Errorf("unexpected domain %s", dom.Name)
+		}
+	}
+}
+
+// TestStop tests that Stop ends following and drains the pipeline
+func TestStop(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+
+	idx := testIndexer(core)
+	idx.Config.PollInterval = 10 * time.Millisecond
+	idx.Config.NamePageWorkers, idx.Config.ResolveWorkers, idx.Config.DBWorkers = 2, 2, 2
+	go func() {
+		defer close(idx.done)
+		idx.startWorkers()
+		defer idx.drain()
+		idx.follow(blockstacktest.FixtureLastBlock)
+	}()
+	time.Sleep(30 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := idx.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, open := <-idx.dbChan; open {
+		t.Fail()
+	}
+	if core.Calls("getinfo") == 0 {
+		t.Fail()
+	}
+}
+
+// failingStore fails every write while failing is set
+type failingStore struct {
+	Store
+	failing int32
+}
+
+func (s *failingStore) UpsertDomains(domains Domains) map[int]error {
+	if atomic.LoadInt32(&s.failing) == 0 {
+		return s.Store.UpsertDomains(domains)
+	}
+	failed := make(map[int]error)
+	for n := range domains {
+		failed[n] = errors.New("write failed")
+	}
+	return failed
+}
+
+// TestFollowRetriesFailures tests that blocks are not marked as indexed until
+// every name changed in them is fetched and written
+func TestFollowRetriesFailures(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	core.AddName("newname.id", "1Bv2vJMqBLrm7pRGTsMqDeoKb8bf6fsBPe", blockstacktest.FixtureLastBlock+1)
+	core.Lock()
+	core.Info.LastBlockProcessed = blockstacktest.FixtureLastBlock + 1
+	core.Unlock()
+	core.SetError("get_name_blockchain_record", "database is locked")
+
+	idx := testIndexer(core)
+	store := &failingStore{Store: idx.store}
+	idx.store = store
+	idx.Config.PollInterval = 5 * time.Millisecond
+	idx.Config.DBFlushInterval = time.Millisecond
+	idx.Config.NamePageWorkers, idx.Config.ResolveWorkers, idx.Config.DBWorkers = 2, 2, 2
+	go func() {
+		defer close(idx.done)
+		idx.startWorkers()
+		defer idx.drain()
+		idx.follow(blockstacktest.FixtureLastBlock)
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		idx.Stop(ctx)
+	}()
+
+	// waitFor polls until done reports true or a second has passed
+	waitFor := func(done func() bool) bool {
+		deadline := time.Now().Add(time.Second)
+		for !done() {
+			if time.Now().After(deadline) {
+				return false
+			}
+			time.Sleep(time.Millisecond)
+		}
+		return true
+	}
+	failedTwice := func() bool { return idx.Summary().NamesFailed >= 2 }
+	indexed := func() bool { block, _ := store.LastIndexedBlock(); return block != 0 }
+
+	if !waitFor(failedTwice) {
+		t.Fatal("expected a failed record fetch to be retried")
+	}
+	if indexed() {
+		t.Errorf("expected the block not to be marked as indexed while its names can not be fetched")
+	}
+
+	atomic.StoreInt32(&store.failing, 1)
+	core.SetError("get_name_blockchain_record", "")
+	before := idx.Summary().NamesFailed
+	if !waitFor(func() bool { return idx.Summary().NamesFailed >= before+2 }) {
+		t.Fatal("expected a failed write to be retried")
+	}
+	if indexed() {
+		t.Errorf("expected the block not to be marked as indexed while its names can not be written")
+	}
+
+	atomic.StoreInt32(&store.failing, 0)
+	if !waitFor(indexed) {
+		t.Fatal("expected the block to be marked as indexed once its names are written")
+	}
+	if _, err := store.GetDomain("newname.id"); err != nil {
+		t.Errorf("expected newname.id to be written: %v", err)
+	}
+}
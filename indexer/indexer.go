@@ -1,17 +1,15 @@
 package indexer
 
 import (
+	"context"
 	"log"
 	"sync"
 
 	"github.com/blockstack/blockstack.go/blockstack"
-	"gopkg.in/mgo.v2"
 )
 
 const (
-	logPrefix       = "[indexer]"
-	mongoDB         = "bsk"
-	mongoCollection = "profiles"
+	logPrefix = "[indexer]"
 )
 
 // The Indexer talks to blockstack-core and resolves all
@@ -21,120 +19,180 @@ type Indexer struct {
 	ExpectedNames int
 	Config        *Config
 
-	mongoConn    *mgo.Session
+	store        Store
+	proofs       *ProofChecker
+	profiles     *ProfileFetcher
 	stats        *indexerStats
+	summary      *summary
 	current      *current
+	indexed      *current
 	namePageChan chan Domains
-	// TODO: Use
-	namePageWait sync.WaitGroup
 	resolveChan  chan *Domain
-	// TODO: Use
-	resolveWait sync.WaitGroup
-	dbChan      chan *Domain
-	// TODO: Use
-	dbWait sync.WaitGroup
+	dbChan       chan *Domain
+
+	// fetchWait tracks the goroutines fetching pages of names to send down the pipeline
+	fetchWait sync.WaitGroup
+	// namePageWait, resolveWait and dbWait track the workers of each stage
+	namePageWait sync.WaitGroup
+	resolveWait  sync.WaitGroup
+	dbWait       sync.WaitGroup
+	// pending tracks the names sent down the pipeline until they are written to the database
+	pending sync.WaitGroup
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
 }
 
-func ensureIndex(s *mgo.Session) {
-	session := s.Copy()
-	session.SetMode(mgo.Monotonic, true)
-	defer session.Close()
-	c := session.DB(mongoDB).C(mongoCollection)
-	index := mgo.Index{
-		Key:        []string{"domain"},
-		Unique:     true,
-		DropDups:   true,
-		Background: true,
-		Sparse:     true,
-	}
-	err := c.EnsureIndex(index)
+// NewIndexer returns a new *Indexer writing to the Store selected by conf
+func NewIndexer(conf *Config) (*Indexer, error) {
+	store, err := NewStore(conf)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	return newIndexer(conf, newIndexerStats(), store), nil
 }
 
-// NewIndexer returns a new *Indexer
-func NewIndexer(conf *Config) *Indexer {
-	log.Println(logPrefix, "Connecting to mongodb at", conf.MongoConnection)
-	session, err := mgo.Dial(conf.MongoConnection)
-	if err != nil {
-		panic(err)
-	}
-	ensureIndex(session)
+// newIndexer returns an *Indexer writing to store
+func newIndexer(conf *Config, stats *indexerStats, store Store) *Indexer {
+	proofs := NewProofChecker(nil)
+	proofs.Endpoint = conf.ProofEndpoint
 	return &Indexer{
 		Config:       conf,
+		store:        store,
+		proofs:       proofs,
+		profiles:     NewProfileFetcher(conf.ProfileTimeout, conf.ProfileMaxSize, conf.ProfileHostConcurrency),
 		namePageChan: make(chan Domains),
 		resolveChan:  make(chan *Domain),
 		dbChan:       make(chan *Domain),
-		stats:        newIndexerStats(),
+		stats:        stats,
+		summary:      &summary{},
 		current:      &current{},
-		mongoConn:    session,
+		indexed:      &current{},
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
 	}
 }
 
-// Start runs the Indexer
+// Store returns the Store the Indexer writes to
+func (i *Indexer) Store() Store {
+	return i.store
+}
+
+// Start runs the Indexer. The first run crawls every name, later runs pick
+// up from the last indexed block. If Config.PollInterval is set the Indexer
+// then keeps following new blocks until Stop is called, otherwise Start
+// returns once the crawl is finished and every name is written
 func (i *Indexer) Start() {
+	i.summary.start()
+	defer close(i.done)
 
 	// Kick off the client updater
-	go i.Config.runClientUpdater()
-
-	// Get the expected number of names in all namespaces
-	log.Println(logPrefix, "Fetching expected number of names...")
-	i.setExpectedNames()
-	log.Println(logPrefix, i.ExpectedNames, "found on the Blockstack Network, fetching...")
-
-	if i.Config.IndexMethod == "byName" {
-		log.Println(logPrefix, "Resolving all names...")
-		// TODO: Add waitGroup handling here and wait until the index is Done
-		// then exit. This will allow for looping!
-		go i.startByNames()
+	go i.Config.runClientUpdater(i.stop)
+	i.startWorkers()
+	defer i.drain()
+
+	last := i.lastIndexedBlock()
+	if last == 0 {
+		// Get the expected number of names in all namespaces
+		log.Println(logPrefix, "Fetching expected number of names...")
+		i.setExpectedNames()
+		log.Println(logPrefix, i.ExpectedNames, "found on the Blockstack Network, fetching...")
+
+		var tip int
+		switch i.Config.IndexMethod {
+		case "byName":
+			log.Println(logPrefix, "Resolving all names...")
+			tip = i.startByNames()
+		case "byBlock":
+			log.Println(logPrefix, "Resolving all names by block...")
+			tip = i.startByBlock()
+		default:
+			log.Printf("%s Invalid indexMethod '%s', byName and byBlock supported", logPrefix, i.Config.IndexMethod)
+			return
+		}
+		if i.stopping() {
+			log.Println(logPrefix, "Crawl interrupted, it will start again on the next run")
+			return
+		}
+		i.pending.Wait()
+		i.saveLastIndexedBlock(tip)
+		last = tip
+		log.Println(logPrefix, "Indexed all names up to block", tip)
+		log.Println(logPrefix, "Crawl finished:", i.Summary())
 	} else {
-		log.Printf("%s Invalid indexMethod '%s', byName supported", logPrefix, i.Config.IndexMethod)
+		log.Println(logPrefix, "Resuming from block", last)
+		i.setCB(last)
+		i.setIndexed(last)
 	}
-}
-
-// client loops through i.Config.clients and returns one
-func (i *Indexer) client() *blockstack.Client {
-	var client *blockstack.Client
 
-	// Lock the config object to prevent concurrent access
-	i.Config.Lock()
+	if i.Config.PollInterval > 0 {
+		i.follow(last)
+	}
+}
 
-	// If there is only one client, return it quickly
-	if len(i.Config.clients) == 1 {
-		client = i.Config.clients[0]
-		i.Config.Unlock()
-		return client
+// Stop stops fetching new names, lets every name already in the pipeline be
+// resolved and written and waits for Start to return. If ctx is done first
+// its error is returned and names may not have been written
+func (i *Indexer) Stop(ctx context.Context) error {
+	i.stopOnce.Do(func() { close(i.stop) })
+	select {
+	case <-i.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
 
-	// Reset the counter if currentClient is greater than the number of clients
-	i.Config.currentClient++
-	if len(i.Config.clients) <= i.Config.currentClient {
-		i.Config.currentClient = 0
+// stopping reports whether Stop has been called
+func (i *Indexer) stopping() bool {
+	select {
+	case <-i.stop:
+		return true
+	default:
+		return false
 	}
+}
 
-	// Return the client at index currentClient
-	client = i.Config.clients[i.Config.currentClient]
+// drain waits for the goroutines fetching names to finish and then closes
+// each stage of the pipeline in order once its workers are done
+func (i *Indexer) drain() {
+	i.fetchWait.Wait()
+	close(i.namePageChan)
+	i.namePageWait.Wait()
+	close(i.resolveChan)
+	i.resolveWait.Wait()
+	close(i.dbChan)
+	i.dbWait.Wait()
+	log.Println(logPrefix, "Stopped:", i.Summary())
+}
 
+// client returns the next client from the pool
+func (i *Indexer) client() *blockstack.Client {
+	i.Config.Lock()
+	pool := i.Config.pool
 	i.Config.Unlock()
 
 	i.stats.callsMade.Add(1)
-	return client
+	return pool.Next()
 }
 
 // Gets the expected number of names from blockstack-core
 func (i *Indexer) setExpectedNames() {
-	res, err := i.client().GetAllNamespaces()
+	res, err := i.GetAllNamespaces()
 	if err != nil {
-		// TODO: Better error handling here
-		panic(err)
+		if i.stopping() {
+			return
+		}
+		log.Fatalln(logPrefix, "Unable to fetch namespaces:", err)
 	}
 
 	// Then find the number of names in each Namespace
 	for _, ns := range res.Namespaces {
-		res, err := i.client().GetNumNamesInNamespace(ns)
+		res, err := i.GetNumNamesInNamespace(ns)
 		if err != nil {
-			panic(err)
+			log.Println(logPrefix, "Unable to count names in", ns, err)
+			continue
 		}
 		i.ExpectedNames += res.Count
 	}
@@ -159,6 +217,21 @@ func (i *Indexer) GetCB() int {
 	return block
 }
 
+// setIndexed records that every name in the Store is indexed up to block
+func (i *Indexer) setIndexed(block int) {
+	i.indexed.Lock()
+	i.indexed.block = block
+	i.indexed.Unlock()
+}
+
+// IndexedBlock returns the block every name in the Store is indexed up to,
+// 0 until the first crawl has finished
+func (i *Indexer) IndexedBlock() int {
+	i.indexed.Lock()
+	defer i.indexed.Unlock()
+	return i.indexed.block
+}
+
 // current holds the value of the current block as well as a mutex to prevent contention
 type current struct {
 	block int
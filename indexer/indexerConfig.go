@@ -9,6 +9,11 @@ import (
 	"github.com/blockstack/blockstack.go/blockstack"
 )
 
+const (
+	defaultDBBatchSize     = 20
+	defaultDBFlushInterval = time.Second
+)
+
 // Config is a config object for the Indexer
 type Config struct {
 	URLs                 []string
@@ -18,38 +23,73 @@ type Config struct {
 	ConcurrentPageFetch  int
 	ClientUpdateInterval int
 	DBBatchSize          int
+	DBFlushInterval      time.Duration
 	DBWorkers            int
 	MongoConnection      string
+	MaxAttempts          int
+	RetryBackoff         time.Duration
+	RetryMaxBackoff      time.Duration
+	Pool                 blockstack.PoolConfig
+	// PollInterval is how often to check for new blocks once the index is
+	// built, 0 stops after building it
+	PollInterval time.Duration
+	// Store is the backend the index is kept in: mongo, bolt or memory.
+	// MongoConnection is used by mongo and StorePath by bolt
+	Store     string
+	StorePath string
+	// CheckProofs fetches the social proofs of every profile resolved,
+	// ProofEndpoint replaces the host they are fetched from
+	CheckProofs   bool
+	ProofEndpoint string
+	// ProfileTimeout, ProfileMaxSize and ProfileHostConcurrency bound each
+	// profile fetch, zero takes the default
+	ProfileTimeout         time.Duration
+	ProfileMaxSize         int64
+	ProfileHostConcurrency int
 
-	clients       []*blockstack.Client
-	currentClient int
+	pool *blockstack.Pool
 
 	sync.Mutex
 }
 
 func (c *Config) String() string {
+	backoff, maxBackoff := c.retryBackoff()
 	return fmt.Sprintf(`Configuration Settings:
   Number of Clients:            %v
+  Client Selection:             %v
   Number of Name Page Workers:  %v
   Number of Resolve Workers:    %v
   Name Page Concurrency:        %v
   Client Update Interval:       %v
   Database Batch Size:          %v
+  Database Flush Interval:      %v
   Database Insert Workers:      %v
-  Mongo Connection:             %v`,
+  Store:                        %v
+  Check Social Proofs:          %v
+  RPC Max Attempts:             %v
+  RPC Retry Backoff:            %v
+  RPC Max Retry Backoff:        %v
+  New Block Poll Interval:      %v`,
 		len(c.URLs),
+		c.Pool.Strategy,
 		c.NamePageWorkers,
 		c.ResolveWorkers,
 		c.ConcurrentPageFetch,
 		c.ClientUpdateInterval,
-		c.DBBatchSize,
+		c.dbBatchSize(),
+		c.dbFlushInterval(),
 		c.DBWorkers,
-		c.MongoConnection,
+		c.storeString(),
+		c.CheckProofs,
+		c.maxAttempts(),
+		backoff,
+		maxBackoff,
+		c.PollInterval,
 	)
 }
 
-// SetClients takes the configured URLs and returns only
-// the blockstack-core nodes that are in consensus
+// SetClients takes the configured URLs and puts only the blockstack-core
+// nodes that are in consensus in the client pool
 func (c *Config) SetClients() {
 	clients, errs := blockstack.ValidClients(c.URLs)
 	for _, err := range errs {
@@ -59,16 +99,51 @@ func (c *Config) SetClients() {
 		}
 	}
 	c.Lock()
-	c.clients = clients
+	if c.pool == nil {
+		c.pool = blockstack.NewPool(c.Pool, clients...)
+	} else {
+		c.pool.Set(clients)
+	}
 	c.Unlock()
 }
 
-// Run as a goroutine to continually update clients
-func (c *Config) runClientUpdater() {
+// Run as a goroutine to continually update clients until stop is closed
+func (c *Config) runClientUpdater(stop <-chan struct{}) {
 	log.Println(logPrefix, "Kicking off client update routine...")
 	for {
-		time.Sleep(time.Duration(c.ClientUpdateInterval) * time.Minute)
+		select {
+		case <-stop:
+			return
+		case <-time.After(time.Duration(c.ClientUpdateInterval) * time.Minute):
+		}
 		log.Println(logPrefix, "Updating blockstack-core clients...")
 		c.SetClients()
 	}
 }
+
+// dbBatchSize returns the number of names to write to the database at once
+func (c *Config) dbBatchSize() int {
+	if c.DBBatchSize <= 0 {
+		return defaultDBBatchSize
+	}
+	return c.DBBatchSize
+}
+
+// dbFlushInterval returns how long a partial batch waits before it is written
+func (c *Config) dbFlushInterval() time.Duration {
+	if c.DBFlushInterval <= 0 {
+		return defaultDBFlushInterval
+	}
+	return c.DBFlushInterval
+}
+
+// storeString describes the configured Store
+func (c *Config) storeString() string {
+	switch c.Store {
+	case "", "mongo":
+		return "mongo " + c.MongoConnection
+	case "bolt":
+		return "bolt " + c.StorePath
+	}
+	return c.Store
+}
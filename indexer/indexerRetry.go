@@ -1,16 +1,177 @@
 package indexer
 
+import (
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+)
+
+const (
+	defaultMaxAttempts     = 5
+	defaultRetryBackoff    = 500 * time.Millisecond
+	defaultRetryMaxBackoff = 30 * time.Second
+)
+
+// retryable reports whether err is worth retrying against another node.
+// Only transport failures and zonefiles that do not match their hash are,
+// errors returned by blockstack-core like "Not found." will be the same on every node
+func retryable(err blockstack.Error) bool {
+	switch err.(type) {
+	case blockstack.CallError, blockstack.ZonefileHashError:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before retry attempt n (starting at 1). The delay
+// doubles each attempt up to the configured max and half of it is jittered
+func (c *Config) backoff(attempt int) time.Duration {
+	base, max := c.retryBackoff()
+	d := base
+	for n := 1; n < attempt && d < max; n++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryBackoff returns the configured base and max retry delays
+func (c *Config) retryBackoff() (time.Duration, time.Duration) {
+	base, max := c.RetryBackoff, c.RetryMaxBackoff
+	if base <= 0 {
+		base = defaultRetryBackoff
+	}
+	if max <= 0 {
+		max = defaultRetryMaxBackoff
+	}
+	return base, max
+}
+
+// maxAttempts returns the configured number of attempts for each RPC call
+func (c *Config) maxAttempts() int {
+	if c.MaxAttempts <= 0 {
+		return defaultMaxAttempts
+	}
+	return c.MaxAttempts
+}
+
+// retry calls fn with the next client from i.client() until it succeeds,
+// returns an error that should not be retried or runs out of attempts.
+// The last error is returned without waiting out the backoff when the Indexer is stopped
+func (i *Indexer) retry(method string, fn func(*blockstack.Client) blockstack.Error) blockstack.Error {
+	var err blockstack.Error
+	attempts := i.Config.maxAttempts()
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = fn(i.client())
+		if err == nil || !retryable(err) {
+			return err
+		}
+		i.stats.callsRetried.Inc()
+		if attempt < attempts {
+			wait := i.Config.backoff(attempt)
+			log.Printf("%s %s failed (attempt %d/%d), retrying in %v: %v", logPrefix, method, attempt, attempts, wait, err)
+			select {
+			case <-time.After(wait):
+			case <-i.stop:
+				return err
+			}
+		}
+	}
+	log.Printf("%s %s failed after %d attempts: %v", logPrefix, method, attempts, err)
+	return err
+}
+
 // GetAllNamespaces implements retries for the RPC method
-func (i *Indexer) GetAllNamespaces() {}
+func (i *Indexer) GetAllNamespaces() (blockstack.GetAllNamespacesResult, blockstack.Error) {
+	var res blockstack.GetAllNamespacesResult
+	err := i.retry("get_all_namespaces", func(c *blockstack.Client) (err blockstack.Error) {
+		res, err = c.GetAllNamespaces()
+		return
+	})
+	return res, err
+}
 
 // GetNumNamesInNamespace implements retries for the RPC method
-func (i *Indexer) GetNumNamesInNamespace() {}
+func (i *Indexer) GetNumNamesInNamespace(ns string) (blockstack.CountResult, blockstack.Error) {
+	var res blockstack.CountResult
+	err := i.retry("get_num_names_in_namespace", func(c *blockstack.Client) (err blockstack.Error) {
+		res, err = c.GetNumNamesInNamespace(ns)
+		return
+	})
+	return res, err
+}
 
 // GetNamesInNamespace implements retries for the RPC method
-func (i *Indexer) GetNamesInNamespace() {}
+func (i *Indexer) GetNamesInNamespace(ns string, offset, count int) (blockstack.GetNamesInNamespaceResult, blockstack.Error) {
+	var res blockstack.GetNamesInNamespaceResult
+	err := i.retry("get_names_in_namespace", func(c *blockstack.Client) (err blockstack.Error) {
+		res, err = c.GetNamesInNamespace(ns, offset, count)
+		return
+	})
+	return res, err
+}
+
+// GetNameBlockchainRecord implements retries for the RPC method
+func (i *Indexer) GetNameBlockchainRecord(name string) (blockstack.GetNameBlockchainRecordResult, blockstack.Error) {
+	var res blockstack.GetNameBlockchainRecordResult
+	err := i.retry("get_name_blockchain_record", func(c *blockstack.Client) (err blockstack.Error) {
+		res, err = c.GetNameBlockchainRecord(name)
+		return
+	})
+	return res, err
+}
 
 // GetNameAt implements retries for the RPC method
-func (i *Indexer) GetNameAt() {}
+func (i *Indexer) GetNameAt(name string, blockHeight int) (blockstack.GetNameAtResult, blockstack.Error) {
+	var res blockstack.GetNameAtResult
+	err := i.retry("get_name_at", func(c *blockstack.Client) (err blockstack.Error) {
+		res, err = c.GetNameAt(name, blockHeight)
+		return
+	})
+	return res, err
+}
 
 // GetZonefiles implements retries for the RPC method
-func (i *Indexer) GetZonefiles() {}
+func (i *Indexer) GetZonefiles(zonefiles []string) (blockstack.GetZonefilesResult, blockstack.Error) {
+	var res blockstack.GetZonefilesResult
+	err := i.retry("get_zonefiles", func(c *blockstack.Client) (err blockstack.Error) {
+		res, err = c.GetZonefiles(zonefiles)
+		return
+	})
+	return res, err
+}
+
+// GetZonefilesByBlock implements retries for the RPC method
+func (i *Indexer) GetZonefilesByBlock(startBlock, endBlock, offset, count int) (blockstack.GetZonefilesByBlockResult, blockstack.Error) {
+	var res blockstack.GetZonefilesByBlockResult
+	err := i.retry("get_zonefiles_by_block", func(c *blockstack.Client) (err blockstack.Error) {
+		res, err = c.GetZonefilesByBlock(startBlock, endBlock, offset, count)
+		return
+	})
+	return res, err
+}
+
+// GetInfo implements retries for the RPC method
+func (i *Indexer) GetInfo() (blockstack.GetInfoResult, blockstack.Error) {
+	var res blockstack.GetInfoResult
+	err := i.retry("getinfo", func(c *blockstack.Client) (err blockstack.Error) {
+		res, err = c.GetInfo()
+		return
+	})
+	return res, err
+}
+
+// GetNameOpsAffectedAt implements retries for the RPC method
+func (i *Indexer) GetNameOpsAffectedAt(blockID, offset, count int) (blockstack.GetNameOpsAffectedAtResult, blockstack.Error) {
+	var res blockstack.GetNameOpsAffectedAtResult
+	err := i.retry("get_nameops_affected_at", func(c *blockstack.Client) (err blockstack.Error) {
+		res, err = c.GetNameOpsAffectedAt(blockID, offset, count)
+		return
+	})
+	return res, err
+}
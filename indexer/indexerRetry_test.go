@@ -0,0 +1,127 @@
+package indexer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+	"github.com/blockstack/blockstack.go/blockstack/blockstacktest"
+)
+
+// stats can only be registered with prometheus once per process
+var stats = newIndexerStats()
+
+// testIndexer returns an *Indexer with a MemoryStore that rotates through the cores
+func testIndexer(cores ...*blockstacktest.Core) *Indexer {
+	cfg := &Config{MaxAttempts: 3, RetryBackoff: time.Millisecond, RetryMaxBackoff: 4 * time.Millisecond, ConcurrentPageFetch: 4}
+	var clients []*blockstack.Client
+	for _, c := range cores {
+		clients = append(clients, c.Client())
+	}
+	cfg.pool = blockstack.NewPool(cfg.Pool, clients...)
+	i := newIndexer(cfg, stats, NewMemoryStore())
+	i.namePageChan = make(chan Domains, 100)
+	// Profiles are fetched from the Profiles of the first core
+	if len(cores) > 0 {
+		i.profiles.Endpoint = cores[0].URL()
+	}
+	return i
+}
+
+// TestRetryRotatesClients tests that a failed call is retried against the next client
+func TestRetryRotatesClients(t *testing.T) {
+	down, up := blockstacktest.NewCore(), blockstacktest.NewCore()
+	defer down.Close()
+	defer up.Close()
+	down.SetUnavailable(true)
+
+	res, err := testIndexer(down, up).GetAllNamespaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Namespaces) != len(up.Namespaces) {
+		t.Fail()
+	}
+	if up.Calls("get_all_namespaces") != 1 {
+		t.Fail()
+	}
+}
+
+// TestRetryZonefileMismatch tests that zonefiles that do not match their hash are fetched from the next client
+func TestRetryZonefileMismatch(t *testing.T) {
+	tampered, good := blockstacktest.NewCore(), blockstacktest.NewCore()
+	defer tampered.Close()
+	defer good.Close()
+	hash := good.Records["muneeb.id"].Record.ValueHash
+	tampered.Zonefiles[hash] = "tampered"
+
+	res, err := testIndexer(tampered, good).GetZonefiles([]string{hash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Decode()[hash] != good.Zonefiles[hash] {
+		t.Errorf("expected the zonefile from the node that served it intact")
+	}
+	if good.Calls("get_zonefiles") != 1 {
+		t.Fail()
+	}
+}
+
+// TestRetryNotFound tests that errors returned by blockstack-core are not retried
+func TestRetryNotFound(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+
+	_, err := testIndexer(core).GetNameBlockchainRecord("doesnotexist.id")
+	if _, ok := err.(blockstack.RPCError); !ok {
+		t.Fail()
+	}
+	if core.Calls("get_name_blockchain_record") != 1 {
+		t.Fail()
+	}
+}
+
+// TestRetryGivesUp tests that the last CallError is returned after MaxAttempts
+func TestRetryGivesUp(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	core.SetUnavailable(true)
+
+	_, err := testIndexer(core).GetNumNamesInNamespace("id")
+	if _, ok := err.(blockstack.CallError); !ok {
+		t.Fail()
+	}
+}
+
+// TestBackoff tests that the delay grows with each attempt and is capped
+func TestBackoff(t *testing.T) {
+	cfg := &Config{RetryBackoff: 100 * time.Millisecond, RetryMaxBackoff: time.Second}
+	for attempt, max := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+		d := cfg.backoff(attempt)
+		if d < max/2 || d > max {
+			t.Errorf("attempt %d: backoff %v outside [%v, %v]", attempt, d, max/2, max)
+		}
+	}
+}
+
+// TestRetryStop tests that Stop does not wait out the backoff of calls to a core that is down
+func TestRetryStop(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	core.SetUnavailable(true)
+
+	idx := testIndexer(core)
+	idx.Config.RetryBackoff, idx.Config.RetryMaxBackoff = 30*time.Second, 30*time.Second
+	idx.Config.ClientUpdateInterval = 60
+	idx.Config.NamePageWorkers, idx.Config.ResolveWorkers, idx.Config.DBWorkers = 1, 1, 1
+	go idx.Start()
+	// Let the first call fail and the retry start waiting
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := idx.Stop(ctx); err != nil {
+		t.Errorf("expected Stop to return before the backoff, got %v", err)
+	}
+}
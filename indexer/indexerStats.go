@@ -10,7 +10,9 @@ const (
 
 type indexerStats struct {
 	callsMade           prometheus.Gauge
+	callsRetried        prometheus.Gauge
 	namePagesFetched    prometheus.Gauge
+	blocksScanned       prometheus.Gauge
 	nameDetailsFetched  prometheus.Gauge
 	zonefilesFetched    prometheus.Gauge
 	namesResolved       prometheus.Gauge
@@ -19,6 +21,7 @@ type indexerStats struct {
 	sentDownResolveChan prometheus.Gauge
 	writtenToDatabase   prometheus.Gauge
 	withProfiles        prometheus.Gauge
+	subdomainsFound     prometheus.Gauge
 }
 
 // TODO: Add histogram for BlockstackCore Calls
@@ -31,12 +34,24 @@ func newIndexerStats() *indexerStats {
 			Name:      "num_made",
 			Help:      "the number of core RPC calls made",
 		}),
+		callsRetried: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: promNameSpace,
+			Subsystem: "core_calls",
+			Name:      "num_retried",
+			Help:      "the number of core RPC calls that failed and were retried",
+		}),
 		namePagesFetched: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace: promNameSpace,
 			Subsystem: "name",
 			Name:      "pages_fetched",
 			Help:      "the number of pages of 100 names fetched",
 		}),
+		blocksScanned: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: promNameSpace,
+			Subsystem: "blocks",
+			Name:      "scanned",
+			Help:      "the number of blocks scanned for changed names while following new blocks",
+		}),
 		nameDetailsFetched: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace: promNameSpace,
 			Subsystem: "name",
@@ -79,9 +94,17 @@ func newIndexerStats() *indexerStats {
 			Name:      "names_with",
 			Help:      "the number of names with profiles",
 		}),
+		subdomainsFound: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: promNameSpace,
+			Subsystem: "subdomains",
+			Name:      "found",
+			Help:      "the number of subdomains found in zonefile TXT records",
+		}),
 	}
 	prometheus.MustRegister(s.callsMade)
+	prometheus.MustRegister(s.callsRetried)
 	prometheus.MustRegister(s.namePagesFetched)
+	prometheus.MustRegister(s.blocksScanned)
 	prometheus.MustRegister(s.nameDetailsFetched)
 	prometheus.MustRegister(s.zonefilesFetched)
 	prometheus.MustRegister(s.namesResolved)
@@ -89,5 +112,6 @@ func newIndexerStats() *indexerStats {
 	prometheus.MustRegister(s.sentDownResolveChan)
 	prometheus.MustRegister(s.writtenToDatabase)
 	prometheus.MustRegister(s.withProfiles)
+	prometheus.MustRegister(s.subdomainsFound)
 	return s
 }
@@ -0,0 +1,58 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Summary reports what the Indexer has done since it was started
+type Summary struct {
+	NamesSeen     int64         `json:"namesSeen"`
+	NamesResolved int64         `json:"namesResolved"`
+	NamesWritten  int64         `json:"namesWritten"`
+	NamesFailed   int64         `json:"namesFailed"`
+	Duration      time.Duration `json:"duration"`
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%d names seen, %d profiles resolved, %d written, %d failed in %v",
+		s.NamesSeen, s.NamesResolved, s.NamesWritten, s.NamesFailed, s.Duration)
+}
+
+// JSON returns the JSON representation of Summary
+func (s Summary) JSON() string {
+	byt, err := json.Marshal(s)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return string(byt)
+}
+
+// summary counts the names going through the pipeline
+type summary struct {
+	seen, resolved, written, failed int64
+	started                         atomic.Value
+}
+
+func (s *summary) start()    { s.started.Store(time.Now()) }
+func (s *summary) see(n int) { atomic.AddInt64(&s.seen, int64(n)) }
+func (s *summary) resolve()  { atomic.AddInt64(&s.resolved, 1) }
+func (s *summary) write()    { atomic.AddInt64(&s.written, 1) }
+func (s *summary) fail()     { atomic.AddInt64(&s.failed, 1) }
+
+// Summary returns the progress of the Indexer since Start was called
+func (i *Indexer) Summary() Summary {
+	out := Summary{
+		NamesSeen:     atomic.LoadInt64(&i.summary.seen),
+		NamesResolved: atomic.LoadInt64(&i.summary.resolved),
+		NamesWritten:  atomic.LoadInt64(&i.summary.written),
+		NamesFailed:   atomic.LoadInt64(&i.summary.failed),
+	}
+	if started, ok := i.summary.started.Load().(time.Time); ok {
+		out.Duration = time.Since(started).Round(time.Millisecond)
+	}
+	return out
+}
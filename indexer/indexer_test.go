@@ -0,0 +1,124 @@
+package indexer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+	"github.com/blockstack/blockstack.go/blockstack/blockstacktest"
+)
+
+// TestStart tests that a crawl writes every name and the block it reached to the Store
+func TestStart(t *testing.T) {
+	for _, method := range []string{"byName", "byBlock"} {
+		core := blockstacktest.NewCore()
+		defer core.Close()
+		core.AddProfile("https://gaia.blockstack.org/hub/17hEAjUUWp5wN9SEGYqxpdtjHKzWVkmHEo/3/profile.json", `[{"token": "token"}]`)
+
+		idx := testIndexer(core)
+		idx.Config.IndexMethod = method
+		idx.Config.ClientUpdateInterval = 60
+		idx.Config.DBFlushInterval = 10 * time.Millisecond
+		idx.Config.NamePageWorkers, idx.Config.ResolveWorkers, idx.Config.DBWorkers = 2, 2, 2
+		idx.Start()
+
+		names := 0
+		for ns := range core.Namespaces {
+			domains, err := idx.Store().ListByNamespace(ns, 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names += len(domains)
+		}
+		if names != len(core.Records) {
+			t.Errorf("%s: expected %d names in the store, got %d", method, len(core.Records), names)
+		}
+
+		d, err := idx.Store().GetDomain("muneeb.id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Status != blockstack.StatusRegistered || d.BlockchainRecord.Record.Address != core.Records["muneeb.id"].Record.Address {
+			t.Errorf("%s: expected muneeb.id to be registered to its owner, got %q %q", method, d.Status, d.BlockchainRecord.Record.Address)
+		}
+		if d.Zonefile == nil || d.Zonefile.Raw != core.Zonefiles[core.Records["muneeb.id"].Record.ValueHash] {
+			t.Errorf("%s: unexpected zonefile for muneeb.id", method)
+		}
+		if p, ok := d.Profile.(*SOProfile); !ok || p.Token != "token" {
+			t.Errorf("%s: expected the profile of muneeb.id to be fetched, got %v", method, d.ProfileError)
+		}
+		if d, err := idx.Store().GetDomain("ryan.id"); err != nil || d.ProfileError == nil || d.ProfileError.Status != 404 {
+			t.Errorf("%s: expected a missing profile for ryan.id", method)
+		}
+		if block, _ := idx.Store().LastIndexedBlock(); block != blockstacktest.FixtureLastBlock || idx.IndexedBlock() != block {
+			t.Errorf("%s: expected last indexed block %d, got %d", method, blockstacktest.FixtureLastBlock, block)
+		}
+	}
+}
+
+// TestNamePageSkipsFailedRecords tests that a name whose record can not be
+// fetched is counted as failed and not sent on to overwrite the stored one
+func TestNamePageSkipsFailedRecords(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	core.Handle("get_name_blockchain_record", func(c *blockstacktest.Core, args []interface{}) (interface{}, error) {
+		name := args[0].(string)
+		if name == "muneeb.id" {
+			return nil, errors.New("database is locked")
+		}
+		rec := c.Records[name]
+		rec.Status = true
+		return rec, nil
+	})
+
+	idx := testIndexer(core)
+	sem := make(chan struct{}, 1)
+	sem <- struct{}{}
+	idx.fetchWait.Add(1)
+	idx.getNamePageAsync(0, "id", sem)
+
+	page := <-idx.namePageChan
+	for _, d := range page {
+		if d.Name == "muneeb.id" {
+			t.Errorf("expected muneeb.id to be left out of the page")
+		}
+	}
+	if s := idx.Summary(); s.NamesFailed != 1 || s.NamesSeen != int64(len(page)) {
+		t.Errorf("expected 1 failed name and %d seen, got %s", len(page), s)
+	}
+}
+
+// TestStartWaitsForPages tests that the index is not marked as built before
+// every page of names has been fetched and written
+func TestStartWaitsForPages(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	core.Handle("get_name_blockchain_record", func(c *blockstacktest.Core, args []interface{}) (interface{}, error) {
+		time.Sleep(20 * time.Millisecond)
+		rec := c.Records[args[0].(string)]
+		rec.Status = true
+		rec.Lastblock = c.Info.LastBlockProcessed
+		return rec, nil
+	})
+
+	idx := testIndexer(core)
+	idx.Config.IndexMethod = "byName"
+	idx.Config.ClientUpdateInterval = 60
+	idx.Config.DBFlushInterval = 10 * time.Millisecond
+	idx.Config.NamePageWorkers, idx.Config.ResolveWorkers, idx.Config.DBWorkers = 2, 2, 2
+	go idx.Start()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for idx.IndexedBlock() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the crawl")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for name := range core.Records {
+		if _, err := idx.Store().GetDomain(name); err != nil {
+			t.Errorf("expected %s to be written before the index was marked built: %v", name, err)
+		}
+	}
+}
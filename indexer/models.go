@@ -9,7 +9,9 @@ import (
 // LegacyProfiles
 type Profile interface {
 	JSON() string
-	Validate() bool
+	Verify(owner string) error
+	Accounts() []Account
+	Person() json.RawMessage
 }
 
 // SOProfile models a Schema.org profile
@@ -30,6 +32,26 @@ func (p SOProfile) JSON() string {
 	return string(byt)
 }
 
+// Accounts satisfies the Profile interface
+func (p SOProfile) Accounts() []Account {
+	return p.DecodedToken.Payload.Claim.Account
+}
+
+// Person satisfies the Profile interface, it returns the claim of the token
+func (p SOProfile) Person() json.RawMessage {
+	var payload struct {
+		Claim json.RawMessage `json:"claim"`
+	}
+	if err := decodeSegment(NewDecodedProfileToken(p.Token).Payload, &payload); err == nil && len(payload.Claim) > 0 {
+		return payload.Claim
+	}
+	byt, err := json.Marshal(p.DecodedToken.Payload.Claim)
+	if err != nil {
+		panic(err)
+	}
+	return byt
+}
+
 // DecodedToken contains most of the profile information
 type DecodedToken struct {
 	Payload   Payload `json:"payload"`
@@ -46,8 +68,7 @@ type Payload struct {
 	ExpiresAt string    `json:"expiresAt"`
 }
 
-// Account models a social media proof
-// TODO: Write method on Account to check proof
+// Account models a social media proof, it is checked by a ProofChecker
 type Account struct {
 	Type       string `json:"@type"`
 	Service    string `json:"service"`
@@ -114,6 +135,71 @@ func (p LegacyProfile) JSON() string {
 	return string(byt)
 }
 
+// Accounts satisfies the Profile interface
+func (p LegacyProfile) Accounts() []Account {
+	var out []Account
+	for _, a := range p.Account {
+		out = append(out, Account{Service: a["service"], Identifier: a["identifier"], ProofURL: a["proofUrl"]})
+	}
+	legacy := []struct {
+		service string
+		proof   LProof
+	}{{"facebook", p.Facebook}, {"github", p.Github}, {"twitter", p.Twitter}}
+	for _, l := range legacy {
+		if l.proof.Proof["url"] != "" {
+			out = append(out, Account{Service: l.service, Identifier: l.proof.Username, ProofURL: l.proof.Proof["url"]})
+		}
+	}
+	return out
+}
+
+// Person satisfies the Profile interface, it converts the legacy
+// format to a Schema.org Person
+func (p LegacyProfile) Person() json.RawMessage {
+	person := map[string]interface{}{"@type": "Person"}
+	if p.Name["formatted"] != "" {
+		person["name"] = p.Name["formatted"]
+	}
+	if p.Bio != "" {
+		person["description"] = p.Bio
+	}
+	var images []Image
+	if p.Avatar["url"] != "" {
+		images = append(images, Image{Type: "ImageObject", Name: "avatar", ContentURL: p.Avatar["url"]})
+	}
+	if p.Cover["url"] != "" {
+		images = append(images, Image{Type: "ImageObject", Name: "cover", ContentURL: p.Cover["url"]})
+	}
+	if len(images) > 0 {
+		person["image"] = images
+	}
+	if p.Website != "" {
+		person["website"] = []map[string]string{{"@type": "WebSite", "url": p.Website}}
+	}
+	if p.Location["formatted"] != "" {
+		person["address"] = map[string]string{"@type": "PostalAddress", "addressLocality": p.Location["formatted"]}
+	}
+	var accounts []Account
+	for _, a := range p.Accounts() {
+		a.Type = "Account"
+		if a.ProofURL != "" {
+			a.ProofType = "http"
+		}
+		accounts = append(accounts, a)
+	}
+	if p.Bitcoin["address"] != "" {
+		accounts = append(accounts, Account{Type: "Account", Service: "bitcoin", Identifier: p.Bitcoin["address"], ProofType: "signature"})
+	}
+	if len(accounts) > 0 {
+		person["account"] = accounts
+	}
+	byt, err := json.Marshal(person)
+	if err != nil {
+		panic(err)
+	}
+	return byt
+}
+
 // type LegacyProfile struct {
 // 	Account []struct {
 // 		Type       string `json:"@type"`
@@ -0,0 +1,244 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	defaultProfileTimeout         = 10 * time.Second
+	defaultProfileMaxSize         = 1 << 20
+	defaultProfileMaxRedirects    = 3
+	defaultProfileHostConcurrency = 8
+)
+
+// Reasons a profile could not be fetched
+const (
+	ProfileNoURI          = "no_uri"
+	ProfileRequestFailed  = "request_failed"
+	ProfileBadStatus      = "bad_status"
+	ProfileTooLarge       = "too_large"
+	ProfileBadContentType = "bad_content_type"
+	ProfileUndecodable    = "undecodable"
+)
+
+// ProfileError records why the profile of a Domain could not be fetched
+type ProfileError struct {
+	Reason string `json:"reason" bson:"reason"`
+	URI    string `json:"uri,omitempty" bson:"uri,omitempty"`
+	Status int    `json:"status,omitempty" bson:"status,omitempty"`
+	Detail string `json:"detail,omitempty" bson:"detail,omitempty"`
+}
+
+func (e *ProfileError) Error() string {
+	msg := e.Reason
+	if e.URI != "" {
+		msg += " " + e.URI
+	}
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// ProfileFetcher fetches profiles from the URI records of zonefiles. Each
+// fetch is bounded in time, size and redirects, and only HostConcurrency
+// fetches run against a host at once
+type ProfileFetcher struct {
+	Client          *http.Client
+	MaxSize         int64
+	MaxRedirects    int
+	HostConcurrency int
+	// Endpoint, when set, replaces the scheme and host of every profile
+	// fetched. The original host is sent in the Host header
+	Endpoint string
+
+	hosts map[string]chan struct{}
+
+	sync.Mutex
+}
+
+// NewProfileFetcher returns a *ProfileFetcher with a client that gives up after timeout.
+// Zero values take the defaults
+func NewProfileFetcher(timeout time.Duration, maxSize int64, hostConcurrency int) *ProfileFetcher {
+	if timeout <= 0 {
+		timeout = defaultProfileTimeout
+	}
+	if maxSize <= 0 {
+		maxSize = defaultProfileMaxSize
+	}
+	if hostConcurrency <= 0 {
+		hostConcurrency = defaultProfileHostConcurrency
+	}
+	pf := &ProfileFetcher{
+		MaxSize:         maxSize,
+		MaxRedirects:    defaultProfileMaxRedirects,
+		HostConcurrency: hostConcurrency,
+		hosts:           make(map[string]chan struct{}),
+	}
+	pf.Client = &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > pf.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", pf.MaxRedirects)
+			}
+			return nil
+		},
+	}
+	return pf
+}
+
+// Fetch tries each URI in zf in order of priority and weight and returns the
+// first profile found. If none is found the error of the last URI is returned
+func (pf *ProfileFetcher) Fetch(zf *Zonefile) (Profile, *ProfileError) {
+	uris := zf.URIs()
+	if len(uris) == 0 {
+		return nil, &ProfileError{Reason: ProfileNoURI}
+	}
+	var err *ProfileError
+	for _, uri := range uris {
+		var p Profile
+		if p, err = pf.fetch(uri.Target); err == nil {
+			return p, nil
+		}
+	}
+	return nil, err
+}
+
+// fetch returns the profile at target
+func (pf *ProfileFetcher) fetch(target string) (Profile, *ProfileError) {
+	fail := func(reason string, status int, detail string) (Profile, *ProfileError) {
+		return nil, &ProfileError{Reason: reason, URI: target, Status: status, Detail: detail}
+	}
+	u, err := profileURL(target)
+	if err != nil {
+		return fail(ProfileRequestFailed, 0, err.Error())
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fail(ProfileRequestFailed, 0, err.Error())
+	}
+	if pf.Endpoint != "" {
+		endpoint, err := url.Parse(pf.Endpoint)
+		if err != nil {
+			return fail(ProfileRequestFailed, 0, err.Error())
+		}
+		req.Host = req.URL.Host
+		req.URL.Scheme, req.URL.Host = endpoint.Scheme, endpoint.Host
+	}
+
+	release := pf.acquire(u.Host)
+	defer release()
+	res, err := pf.Client.Do(req)
+	if err != nil {
+		return fail(ProfileRequestFailed, 0, err.Error())
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fail(ProfileBadStatus, res.StatusCode, res.Status)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, pf.MaxSize+1))
+	if err != nil {
+		return fail(ProfileRequestFailed, res.StatusCode, err.Error())
+	}
+	if int64(len(body)) > pf.MaxSize {
+		return fail(ProfileTooLarge, res.StatusCode, fmt.Sprintf("larger than %d bytes", pf.MaxSize))
+	}
+	if !looksLikeJSON(res.Header.Get("Content-Type"), body) {
+		return fail(ProfileBadContentType, res.StatusCode, res.Header.Get("Content-Type"))
+	}
+	p, err := decodeProfile(body)
+	if err != nil {
+		return fail(ProfileUndecodable, res.StatusCode, err.Error())
+	}
+	return p, nil
+}
+
+// acquire waits for a free slot for host and returns the func that frees it
+func (pf *ProfileFetcher) acquire(host string) func() {
+	pf.Lock()
+	slots, ok := pf.hosts[host]
+	if !ok {
+		slots = make(chan struct{}, pf.HostConcurrency)
+		pf.hosts[host] = slots
+	}
+	pf.Unlock()
+	slots <- struct{}{}
+	return func() { <-slots }
+}
+
+// profileURL parses a URI target, targets without a scheme are fetched over https
+func profileURL(target string) (*url.URL, error) {
+	if !strings.Contains(target, "://") {
+		target = "https://" + target
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	return u, nil
+}
+
+// looksLikeJSON reports whether a body served with contentType can be a profile.
+// Storage providers often serve JSON as text or octet-stream, so anything not
+// declared as JSON is sniffed
+func looksLikeJSON(contentType string, body []byte) bool {
+	media, _, _ := mime.ParseMediaType(contentType)
+	if media == "application/json" || strings.HasSuffix(media, "+json") {
+		return true
+	}
+	trimmed := bytes.TrimSpace(body)
+	return len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{')
+}
+
+// decodeProfile decodes a token file, a list of signed profiles, or a single signed profile
+func decodeProfile(body []byte) (Profile, error) {
+	var list []SOProfile
+	if err := json.Unmarshal(body, &list); err == nil {
+		if len(list) == 0 || list[0].Token == "" {
+			return nil, errors.New("no profile token in the token file")
+		}
+		return &list[0], nil
+	}
+	var p SOProfile
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
+	}
+	if p.Token == "" {
+		return nil, errors.New("no profile token")
+	}
+	return &p, nil
+}
+
+// URIs returns the URI records of zf ordered by priority, then weight
+func (zf *Zonefile) URIs() []*dns.URI {
+	var uris []*dns.URI
+	for _, rr := range zf.RRs {
+		if uri, ok := rr.(*dns.URI); ok && uri.Target != "" {
+			uris = append(uris, uri)
+		}
+	}
+	sort.SliceStable(uris, func(i, j int) bool {
+		if uris[i].Priority != uris[j].Priority {
+			return uris[i].Priority < uris[j].Priority
+		}
+		return uris[i].Weight > uris[j].Weight
+	})
+	return uris
+}
@@ -0,0 +1,82 @@
+package indexer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestFetchProfile tests each reason a profile fetch can fail and the fallback across URIs
+func TestFetchProfile(t *testing.T) {
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tokenfile.json":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(`[{"token": "token", "decodedToken": {}}]`))
+		case "/profile.json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"token": "token"}`))
+		case "/large.json":
+			w.Write([]byte(`[` + strings.Repeat(" ", 100) + `]`))
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html></html>`))
+		case "/empty.json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[]`))
+		case "/redirect":
+			http.Redirect(w, r, "/redirect", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer stub.Close()
+
+	pf := NewProfileFetcher(0, 64, 1)
+	pf.Endpoint = stub.URL
+	tests := []struct {
+		uris   []string
+		reason string
+	}{
+		{[]string{"https://example.com/tokenfile.json"}, ""},
+		{[]string{"example.com/profile.json"}, ""},
+		{nil, ProfileNoURI},
+		{[]string{"https://example.com/missing.json"}, ProfileBadStatus},
+		{[]string{"https://example.com/large.json"}, ProfileTooLarge},
+		{[]string{"https://example.com/page.html"}, ProfileBadContentType},
+		{[]string{"https://example.com/empty.json"}, ProfileUndecodable},
+		{[]string{"https://example.com/redirect"}, ProfileRequestFailed},
+		{[]string{"file:///tmp/profile.json"}, ProfileRequestFailed},
+	}
+	for _, test := range tests {
+		zf := "$ORIGIN muneeb.id\n$TTL 3600\n"
+		for _, uri := range test.uris {
+			zf += "_http._tcp IN URI 10 1 \"" + uri + "\"\n"
+		}
+		d := NewDomain("muneeb.id")
+		d.AddZonefile(zf)
+		d.ResolveProfile(pf)
+		if test.reason == "" && (d.ProfileError != nil || d.Profile == nil) {
+			t.Errorf("%v: expected a profile, got %v", test.uris, d.ProfileError)
+		}
+		if test.reason != "" && (d.ProfileError == nil || d.ProfileError.Reason != test.reason) {
+			t.Errorf("%v: expected %s, got %v", test.uris, test.reason, d.ProfileError)
+		}
+	}
+
+	// URIs are tried in order of priority then weight until one resolves
+	d := NewDomain("muneeb.id")
+	d.AddZonefile("$ORIGIN muneeb.id\n$TTL 3600\n" +
+		"_http._tcp IN URI 20 1 \"https://example.com/profile.json\"\n" +
+		"_http._tcp IN URI 10 1 \"https://example.com/page.html\"\n" +
+		"_http._tcp IN URI 10 5 \"https://example.com/missing.json\"\n")
+	uris := d.Zonefile.URIs()
+	if len(uris) != 3 || uris[0].Target != "https://example.com/missing.json" || uris[2].Target != "https://example.com/profile.json" {
+		t.Errorf("unexpected URI order %v", uris)
+	}
+	d.ResolveProfile(pf)
+	if d.Profile == nil || d.ProfileError != nil {
+		t.Errorf("expected a profile from the last URI, got %v", d.ProfileError)
+	}
+}
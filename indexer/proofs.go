@@ -0,0 +1,226 @@
+package indexer
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultProofTimeout = 10 * time.Second
+	// maxProofSize is the most read from a proof page
+	maxProofSize = 1 << 20
+)
+
+// ProofVerification is the outcome of checking the social proof of an Account
+type ProofVerification struct {
+	Service    string `json:"service" bson:"service"`
+	Identifier string `json:"identifier" bson:"identifier"`
+	ProofURL   string `json:"proof_url" bson:"proofUrl"`
+	Valid      bool   `json:"valid" bson:"valid"`
+	Error      string `json:"error,omitempty" bson:"error,omitempty"`
+}
+
+// ProofService holds the rules for checking the proofs of one social service
+type ProofService struct {
+	// Hosts are the hosts proof URLs of the service may point at
+	Hosts []string
+	// Identifier returns the account a proof URL belongs to
+	Identifier func(u *url.URL) string
+	// FetchURL returns the URL the proof statement is read from, when nil it
+	// is read from the proof URL
+	FetchURL func(u *url.URL) string
+}
+
+// ProofChecker fetches the proofs of profile accounts and checks they
+// hold a statement naming the Blockstack ID
+type ProofChecker struct {
+	Client *http.Client
+	// Endpoint, when set, replaces the scheme and host of every proof fetched.
+	// The original host is sent in the Host header, this is used to point the
+	// checker at a local stub
+	Endpoint string
+
+	services map[string]ProofService
+
+	sync.Mutex
+}
+
+// NewProofChecker returns a *ProofChecker for GitHub, Twitter, Facebook and
+// HackerNews using client, a client with a timeout is used if client is nil
+func NewProofChecker(client *http.Client) *ProofChecker {
+	if client == nil {
+		client = &http.Client{Timeout: defaultProofTimeout}
+	}
+	pc := &ProofChecker{Client: client, services: make(map[string]ProofService)}
+	pc.Register("github", ProofService{
+		Hosts:      []string{"gist.github.com"},
+		Identifier: firstPathElement,
+		FetchURL: func(u *url.URL) string {
+			return "https://gist.githubusercontent.com" + strings.TrimSuffix(u.Path, "/") + "/raw"
+		},
+	})
+	pc.Register("twitter", ProofService{
+		Hosts:      []string{"twitter.com", "www.twitter.com", "mobile.twitter.com"},
+		Identifier: firstPathElement,
+	})
+	pc.Register("facebook", ProofService{
+		Hosts:      []string{"facebook.com", "www.facebook.com", "m.facebook.com"},
+		Identifier: firstPathElement,
+	})
+	pc.Register("hackernews", ProofService{
+		Hosts:      []string{"news.ycombinator.com"},
+		Identifier: func(u *url.URL) string { return u.Query().Get("id") },
+	})
+	return pc
+}
+
+// Register adds or replaces the rules for service
+func (pc *ProofChecker) Register(service string, s ProofService) {
+	pc.Lock()
+	pc.services[strings.ToLower(service)] = s
+	pc.Unlock()
+}
+
+// service returns the rules for service
+func (pc *ProofChecker) service(service string) (ProofService, bool) {
+	pc.Lock()
+	defer pc.Unlock()
+	s, ok := pc.services[strings.ToLower(service)]
+	return s, ok
+}
+
+// CheckProfile checks the proof of every account in p for name
+func (pc *ProofChecker) CheckProfile(name string, p Profile) []ProofVerification {
+	var out []ProofVerification
+	for _, a := range p.Accounts() {
+		if a.ProofURL == "" {
+			continue
+		}
+		out = append(out, pc.Check(name, a))
+	}
+	return out
+}
+
+// Check fetches the proof of a and checks it belongs to the account and names name
+func (pc *ProofChecker) Check(name string, a Account) ProofVerification {
+	v := ProofVerification{Service: a.Service, Identifier: a.Identifier, ProofURL: a.ProofURL}
+	if err := pc.check(name, a); err != nil {
+		v.Error = err.Error()
+	} else {
+		v.Valid = true
+	}
+	return v
+}
+
+// check returns why the proof of a does not hold for name, nil if it does
+func (pc *ProofChecker) check(name string, a Account) error {
+	s, ok := pc.service(a.Service)
+	if !ok {
+		return fmt.Errorf("unsupported service %q", a.Service)
+	}
+	u, err := url.Parse(a.ProofURL)
+	if err != nil {
+		return fmt.Errorf("invalid proof url: %v", err)
+	}
+	if !hasHost(s.Hosts, u.Host) {
+		return fmt.Errorf("proof url host %q is not a %s host", u.Host, a.Service)
+	}
+	if id := s.Identifier(u); !strings.EqualFold(id, a.Identifier) {
+		return fmt.Errorf("proof url belongs to %q not %q", id, a.Identifier)
+	}
+	fetch := a.ProofURL
+	if s.FetchURL != nil {
+		fetch = s.FetchURL(u)
+	}
+	body, err := pc.fetch(fetch)
+	if err != nil {
+		return err
+	}
+	if !containsProofStatement(string(body), name) {
+		return fmt.Errorf("proof does not contain a statement for %s", name)
+	}
+	return nil
+}
+
+// fetch returns at most maxProofSize bytes of the body at target
+func (pc *ProofChecker) fetch(target string) ([]byte, error) {
+	req, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if pc.Endpoint != "" {
+		endpoint, err := url.Parse(pc.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		req.Host = req.URL.Host
+		req.URL.Scheme, req.URL.Host = endpoint.Scheme, endpoint.Host
+	}
+	res, err := pc.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching proof: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching proof: %s", res.Status)
+	}
+	return ioutil.ReadAll(io.LimitReader(res.Body, maxProofSize))
+}
+
+// CheckProofs checks the social proofs of the accounts in d's profile
+func (d *Domain) CheckProofs(pc *ProofChecker) {
+	if d.Profile == nil {
+		d.Proofs = nil
+		return
+	}
+	d.Proofs = pc.CheckProfile(d.Name, d.Profile)
+}
+
+// containsProofStatement reports whether text holds one of the statements
+// Blockstack clients have used to claim an account for name
+func containsProofStatement(text, name string) bool {
+	text = strings.ToLower(text)
+	name = strings.ToLower(name)
+	username := strings.TrimSuffix(name, ".id")
+	statements := []string{
+		"verifying myself: my bitcoin username is +" + username,
+		"verifying myself: my bitcoin username is " + username,
+		"verifying myself: my openname is " + username,
+		"verifying that +" + username + " is my bitcoin username",
+		"verifying that " + username + " is my bitcoin username",
+		"verifying that " + username + " is my openname",
+		"verifying that +" + username + " is my openname",
+		"verifying i am +" + username + " on my passcard",
+		"verifying that +" + username + " is my blockchain id",
+		"verifying that \"" + name + "\" is my blockstack id",
+		"verifying that " + name + " is my blockstack id",
+		"verifying that &quot;" + name + "&quot; is my blockstack id",
+	}
+	for _, s := range statements {
+		if strings.Contains(text, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasHost reports whether host is one of hosts
+func hasHost(hosts []string, host string) bool {
+	for _, h := range hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstPathElement returns the first element of the path of u
+func firstPathElement(u *url.URL) string {
+	return strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)[0]
+}
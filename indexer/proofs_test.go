@@ -0,0 +1,62 @@
+package indexer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestCheckProofs tests the proofs of each service against a local stub
+func TestCheckProofs(t *testing.T) {
+	statement := "Verifying that \"muneeb.id\" is my Blockstack ID."
+	pages := map[string]string{
+		"gist.githubusercontent.com/muneeb-ali/1234/raw": statement,
+		"twitter.com/muneeb/status/1":                    "<p>" + statement + "</p>",
+		"www.facebook.com/muneeb.ali/posts/1":            "Verifying that &quot;muneeb.id&quot; is my Blockstack ID",
+		"news.ycombinator.com/user":                      "verifying that +muneeb is my blockchain id",
+		"twitter.com/muneeb/status/2":                    "Verifying that \"ryan.id\" is my Blockstack ID.",
+	}
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.Host+r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, page)
+	}))
+	defer stub.Close()
+
+	pc := NewProofChecker(stub.Client())
+	pc.Endpoint = stub.URL
+
+	tests := []struct {
+		account Account
+		valid   bool
+		err     string
+	}{
+		{Account{Service: "github", Identifier: "muneeb-ali", ProofURL: "https://gist.github.com/muneeb-ali/1234"}, true, ""},
+		{Account{Service: "twitter", Identifier: "Muneeb", ProofURL: "https://twitter.com/muneeb/status/1"}, true, ""},
+		{Account{Service: "facebook", Identifier: "muneeb.ali", ProofURL: "https://www.facebook.com/muneeb.ali/posts/1"}, true, ""},
+		{Account{Service: "hackernews", Identifier: "muneeb", ProofURL: "https://news.ycombinator.com/user?id=muneeb"}, true, ""},
+		{Account{Service: "twitter", Identifier: "muneeb", ProofURL: "https://twitter.com/muneeb/status/2"}, false, "statement"},
+		{Account{Service: "twitter", Identifier: "ryan", ProofURL: "https://twitter.com/muneeb/status/1"}, false, "belongs to"},
+		{Account{Service: "twitter", Identifier: "muneeb", ProofURL: "https://example.com/muneeb/status/1"}, false, "host"},
+		{Account{Service: "twitter", Identifier: "muneeb", ProofURL: "https://twitter.com/muneeb/status/3"}, false, "404"},
+		{Account{Service: "myspace", Identifier: "muneeb", ProofURL: "https://myspace.com/muneeb"}, false, "unsupported"},
+	}
+	for _, test := range tests {
+		v := pc.Check("muneeb.id", test.account)
+		if v.Valid != test.valid || !strings.Contains(v.Error, test.err) {
+			t.Errorf("%s: expected valid %v with error %q, got %v %q", test.account.ProofURL, test.valid, test.err, v.Valid, v.Error)
+		}
+	}
+
+	d := NewDomain("muneeb.id")
+	d.Profile = LegacyProfile{Twitter: LProof{Username: "muneeb", Proof: map[string]string{"url": "https://twitter.com/muneeb/status/1"}}}
+	d.CheckProofs(pc)
+	if len(d.Proofs) != 1 || !d.Proofs[0].Valid || d.Proofs[0].Service != "twitter" {
+		t.Errorf("unexpected proofs %v", d.Proofs)
+	}
+}
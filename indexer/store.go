@@ -0,0 +1,157 @@
+package indexer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+)
+
+// ErrNotFound is returned by a Store for a name it does not hold
+var ErrNotFound = errors.New("not found")
+
+// Store persists the *Domain documents and progress of the Indexer
+type Store interface {
+	// UpsertDomains writes each domain keyed by its name. It returns the
+	// error for every domain that failed to write keyed by its position in domains
+	UpsertDomains(domains Domains) map[int]error
+
+	// GetDomain returns the domain for name or ErrNotFound
+	GetDomain(name string) (*Domain, error)
+
+	// ListByNamespace returns count on-chain names from namespace ordered by name
+	// starting at offset. A count of 0 or less returns every domain after offset
+	ListByNamespace(namespace string, offset, count int) (Domains, error)
+
+	// ListByOwner returns the names and subdomains owned by address ordered by name
+	ListByOwner(address string) (Domains, error)
+
+	// LastIndexedBlock returns the block the index was last brought up to, 0 if it never was
+	LastIndexedBlock() (int, error)
+
+	// SetLastIndexedBlock records that every name changed up to block is in the store
+	SetLastIndexedBlock(block int) error
+
+	Close() error
+}
+
+// NewStore opens the Store selected by conf.Store: mongo at conf.MongoConnection,
+// bolt at conf.StorePath or memory
+func NewStore(conf *Config) (Store, error) {
+	switch conf.Store {
+	case "", "mongo":
+		return NewMongoStore(conf.MongoConnection)
+	case "bolt":
+		return NewBoltStore(conf.StorePath)
+	case "memory":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown store %q, mongo, bolt and memory supported", conf.Store)
+	}
+}
+
+// storedDomain is a *Domain as it is kept in a Store. The zonefile resource
+// records and profile are interfaces, so the raw zonefile and profile are kept
+// instead and parsed again on read. The blockchain record is kept as JSON
+// because its history is keyed by block number
+type storedDomain struct {
+	Name         string               `json:"name" bson:"name"`
+	Namespace    string               `json:"namespace" bson:"namespace"`
+	Address      string               `json:"address" bson:"address"`
+	Status       string               `json:"status" bson:"status"`
+	Zonefile     string               `json:"zonefile" bson:"zonefile"`
+	Profile      string               `json:"profile,omitempty" bson:"profile,omitempty"`
+	Record       string               `json:"record" bson:"record"`
+	Subdomain    *Subdomain           `json:"subdomain,omitempty" bson:"subdomain,omitempty"`
+	Verification *ProfileVerification `json:"verification,omitempty" bson:"verification,omitempty"`
+	Proofs       []ProofVerification  `json:"proofs,omitempty" bson:"proofs,omitempty"`
+	ProfileError *ProfileError        `json:"profileError,omitempty" bson:"profileError,omitempty"`
+	LastResolved time.Time            `json:"lastResolved" bson:"lastResolved"`
+}
+
+// newStoredDomain returns the storedDomain for d
+func newStoredDomain(d *Domain) (storedDomain, error) {
+	sd := storedDomain{
+		Name:         d.Name,
+		Address:      d.BlockchainRecord.Record.Address,
+		Status:       blockstack.NameStatus(d.BlockchainRecord),
+		Subdomain:    d.Subdomain,
+		Verification: d.Verification,
+		Proofs:       d.Proofs,
+		ProfileError: d.ProfileError,
+		LastResolved: d.lastResolved,
+	}
+	// Like get_names_in_namespace, namespace listings only hold on-chain names
+	if d.Subdomain == nil {
+		sd.Namespace = namespaceOf(d.Name)
+	}
+	if d.Zonefile != nil {
+		sd.Zonefile = d.Zonefile.Raw
+	}
+	// Legacy profiles are parsed from the zonefile
+	switch p := d.Profile.(type) {
+	case SOProfile:
+		sd.Profile = p.JSON()
+	case *SOProfile:
+		sd.Profile = p.JSON()
+	}
+	byt, err := json.Marshal(d.BlockchainRecord)
+	if err != nil {
+		return sd, err
+	}
+	sd.Record = string(byt)
+	return sd, nil
+}
+
+// domain returns the *Domain sd was made from
+func (sd storedDomain) domain() (*Domain, error) {
+	d := NewDomain(sd.Name)
+	d.Subdomain = sd.Subdomain
+	d.Status = sd.Status
+	d.Verification = sd.Verification
+	d.Proofs = sd.Proofs
+	d.ProfileError = sd.ProfileError
+	d.lastResolved = sd.LastResolved
+	if sd.Zonefile != "" {
+		d.AddZonefile(sd.Zonefile)
+	}
+	if sd.Profile != "" {
+		var p SOProfile
+		if err := json.Unmarshal([]byte(sd.Profile), &p); err != nil {
+			return nil, err
+		}
+		d.Profile = &p
+	}
+	if err := json.Unmarshal([]byte(sd.Record), &d.BlockchainRecord); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// namespaceOf returns the namespace of a name or subdomain
+func namespaceOf(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// sortDomains orders domains by name
+func sortDomains(domains Domains) {
+	sort.Slice(domains, func(a, b int) bool { return domains[a].Name < domains[b].Name })
+}
+
+// domainPage returns count domains starting at offset, every domain after offset if count <= 0
+func domainPage(domains Domains, offset, count int) Domains {
+	if offset >= len(domains) {
+		return Domains{}
+	}
+	if offset > 0 {
+		domains = domains[offset:]
+	}
+	if count > 0 && count < len(domains) {
+		domains = domains[:count]
+	}
+	return domains
+}
@@ -0,0 +1,205 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	domainsBucket    = []byte("domains")
+	namespacesBucket = []byte("namespaces")
+	ownersBucket     = []byte("owners")
+	stateBucket      = []byte("state")
+	lastBlockKey     = []byte("lastBlock")
+)
+
+// BoltStore is a Store kept in a single BoltDB file. Domains are keyed by
+// name, the namespaces and owners buckets index them by
+// namespace or address followed by a zero byte and the name.
+// A BoltDB file can only be opened by one process at a time
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens or creates the BoltDB file at path
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{domainsBucket, namespacesBucket, ownersBucket, stateBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// indexKey returns the key of name in the namespaces or owners bucket
+func indexKey(prefix, name string) []byte {
+	return []byte(prefix + "\x00" + name)
+}
+
+// UpsertDomains satisfies the Store interface. The batch is written in one
+// transaction, if that fails each domain is written in its own so one failed
+// name does not stop the rest of the batch
+func (s *BoltStore) UpsertDomains(domains Domains) map[int]error {
+	failed := make(map[int]error)
+	sds := make(map[int]storedDomain, len(domains))
+	for n, d := range domains {
+		sd, err := newStoredDomain(d)
+		if err != nil {
+			failed[n] = err
+			continue
+		}
+		sds[n] = sd
+	}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, sd := range sds {
+			if err := putDomain(tx, sd); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return failed
+	}
+	for n, sd := range sds {
+		if err := s.db.Update(func(tx *bolt.Tx) error { return putDomain(tx, sd) }); err != nil {
+			failed[n] = err
+		}
+	}
+	return failed
+}
+
+// putDomain writes sd and moves its index entries if the namespace or owner changed
+func putDomain(tx *bolt.Tx, sd storedDomain) error {
+	domains, namespaces, owners := tx.Bucket(domainsBucket), tx.Bucket(namespacesBucket), tx.Bucket(ownersBucket)
+	if byt := domains.Get([]byte(sd.Name)); byt != nil {
+		var old storedDomain
+		if err := json.Unmarshal(byt, &old); err != nil {
+			return err
+		}
+		if err := namespaces.Delete(indexKey(old.Namespace, old.Name)); err != nil {
+			return err
+		}
+		if err := owners.Delete(indexKey(old.Address, old.Name)); err != nil {
+			return err
+		}
+	}
+	byt, err := json.Marshal(sd)
+	if err != nil {
+		return err
+	}
+	if err := domains.Put([]byte(sd.Name), byt); err != nil {
+		return err
+	}
+	if err := namespaces.Put(indexKey(sd.Namespace, sd.Name), nil); err != nil {
+		return err
+	}
+	return owners.Put(indexKey(sd.Address, sd.Name), nil)
+}
+
+// GetDomain satisfies the Store interface
+func (s *BoltStore) GetDomain(name string) (*Domain, error) {
+	var sd storedDomain
+	err := s.db.View(func(tx *bolt.Tx) error {
+		byt := tx.Bucket(domainsBucket).Get([]byte(name))
+		if byt == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(byt, &sd)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sd.domain()
+}
+
+// ListByNamespace satisfies the Store interface
+func (s *BoltStore) ListByNamespace(namespace string, offset, count int) (Domains, error) {
+	return s.list(namespacesBucket, namespace, offset, count)
+}
+
+// ListByOwner satisfies the Store interface
+func (s *BoltStore) ListByOwner(address string) (Domains, error) {
+	return s.list(ownersBucket, address, 0, 0)
+}
+
+// list walks the index bucket entries for prefix, which are ordered by name,
+// and returns count domains starting at offset
+func (s *BoltStore) list(bucket []byte, prefix string, offset, count int) (Domains, error) {
+	var sds []storedDomain
+	err := s.db.View(func(tx *bolt.Tx) error {
+		domains := tx.Bucket(domainsBucket)
+		start := indexKey(prefix, "")
+		c := tx.Bucket(bucket).Cursor()
+		skipped := 0
+		for k, _ := c.Seek(start); k != nil && bytes.HasPrefix(k, start); k, _ = c.Next() {
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			if count > 0 && len(sds) == count {
+				break
+			}
+			var sd storedDomain
+			if err := json.Unmarshal(domains.Get(k[len(start):]), &sd); err != nil {
+				return err
+			}
+			sds = append(sds, sd)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make(Domains, 0, len(sds))
+	for _, sd := range sds {
+		d, err := sd.domain()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// LastIndexedBlock satisfies the Store interface
+func (s *BoltStore) LastIndexedBlock() (int, error) {
+	var block int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		byt := tx.Bucket(stateBucket).Get(lastBlockKey)
+		if byt == nil {
+			return nil
+		}
+		var err error
+		block, err = strconv.Atoi(string(byt))
+		return err
+	})
+	return block, err
+}
+
+// SetLastIndexedBlock satisfies the Store interface
+func (s *BoltStore) SetLastIndexedBlock(block int) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(stateBucket).Put(lastBlockKey, []byte(strconv.Itoa(block)))
+	})
+}
+
+// Close satisfies the Store interface
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
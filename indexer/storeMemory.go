@@ -0,0 +1,103 @@
+package indexer
+
+import (
+	"sync"
+)
+
+// MemoryStore is a Store that keeps everything in memory, for tests and
+// small deployments that can rebuild the index on every start
+type MemoryStore struct {
+	domains   map[string]storedDomain
+	lastBlock int
+
+	sync.Mutex
+}
+
+// NewMemoryStore returns an empty *MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{domains: make(map[string]storedDomain)}
+}
+
+// UpsertDomains satisfies the Store interface
+func (s *MemoryStore) UpsertDomains(domains Domains) map[int]error {
+	failed := make(map[int]error)
+	s.Lock()
+	defer s.Unlock()
+	for n, d := range domains {
+		sd, err := newStoredDomain(d)
+		if err != nil {
+			failed[n] = err
+			continue
+		}
+		s.domains[d.Name] = sd
+	}
+	return failed
+}
+
+// GetDomain satisfies the Store interface
+func (s *MemoryStore) GetDomain(name string) (*Domain, error) {
+	s.Lock()
+	sd, ok := s.domains[name]
+	s.Unlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return sd.domain()
+}
+
+// ListByNamespace satisfies the Store interface
+func (s *MemoryStore) ListByNamespace(namespace string, offset, count int) (Domains, error) {
+	domains, err := s.list(func(sd storedDomain) bool { return sd.Namespace == namespace })
+	if err != nil {
+		return nil, err
+	}
+	return domainPage(domains, offset, count), nil
+}
+
+// ListByOwner satisfies the Store interface
+func (s *MemoryStore) ListByOwner(address string) (Domains, error) {
+	return s.list(func(sd storedDomain) bool { return sd.Address == address })
+}
+
+// list returns the domains matching keep ordered by name
+func (s *MemoryStore) list(keep func(storedDomain) bool) (Domains, error) {
+	s.Lock()
+	var matched []storedDomain
+	for _, sd := range s.domains {
+		if keep(sd) {
+			matched = append(matched, sd)
+		}
+	}
+	s.Unlock()
+
+	domains := make(Domains, 0, len(matched))
+	for _, sd := range matched {
+		d, err := sd.domain()
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, d)
+	}
+	sortDomains(domains)
+	return domains, nil
+}
+
+// LastIndexedBlock satisfies the Store interface
+func (s *MemoryStore) LastIndexedBlock() (int, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.lastBlock, nil
+}
+
+// SetLastIndexedBlock satisfies the Store interface
+func (s *MemoryStore) SetLastIndexedBlock(block int) error {
+	s.Lock()
+	s.lastBlock = block
+	s.Unlock()
+	return nil
+}
+
+// Close satisfies the Store interface
+func (s *MemoryStore) Close() error {
+	return nil
+}
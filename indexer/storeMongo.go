@@ -0,0 +1,198 @@
+package indexer
+
+import (
+	"log"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	mongoDB = "bsk"
+	// mongoCollection holds storedDomain documents. Earlier versions kept
+	// names in a differently shaped profiles collection, which is left alone
+	mongoCollection = "domains"
+	stateCollection = "state"
+	// stateID is the state document of mongoCollection, so a new collection starts with a full crawl
+	stateID = "domains"
+)
+
+// indexerState is the document the Indexer keeps its progress in
+type indexerState struct {
+	ID        string `bson:"_id"`
+	LastBlock int    `bson:"lastBlock"`
+}
+
+// MongoStore is a Store backed by a mongodb server
+type MongoStore struct {
+	session *mgo.Session
+}
+
+// NewMongoStore connects to the mongodb server at url and ensures the indexes
+func NewMongoStore(url string) (*MongoStore, error) {
+	log.Println(logPrefix, "Connecting to mongodb at", url)
+	session, err := mgo.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureIndex(session); err != nil {
+		session.Close()
+		return nil, err
+	}
+	return &MongoStore{session: session}, nil
+}
+
+func ensureIndex(s *mgo.Session) error {
+	session := s.Copy()
+	session.SetMode(mgo.Monotonic, true)
+	defer session.Close()
+	c := session.DB(mongoDB).C(mongoCollection)
+	indexes := []mgo.Index{
+		{
+			Key:        []string{"name"},
+			Unique:     true,
+			Background: true,
+		},
+		{Key: []string{"namespace", "name"}, Background: true},
+		{Key: []string{"address", "name"}, Background: true},
+	}
+	for _, index := range indexes {
+		if err := c.EnsureIndex(index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpsertDomains satisfies the Store interface. The writes are unordered so
+// one failed name does not stop the rest of the batch
+func (s *MongoStore) UpsertDomains(domains Domains) map[int]error {
+	failed := make(map[int]error)
+	if len(domains) == 0 {
+		return failed
+	}
+	session := s.session.Copy()
+	defer session.Close()
+
+	// The bulk only holds the names that could be encoded, index maps
+	// the position of each write back to its position in domains
+	bulk := session.DB(mongoDB).C(mongoCollection).Bulk()
+	bulk.Unordered()
+	var index []int
+	for n, d := range domains {
+		sd, err := newStoredDomain(d)
+		if err != nil {
+			failed[n] = err
+			continue
+		}
+		bulk.Upsert(bson.M{"name": sd.Name}, sd)
+		index = append(index, n)
+	}
+	if len(index) == 0 {
+		return failed
+	}
+	_, err := bulk.Run()
+	for n, e := range batchErrors(err, len(index)) {
+		failed[index[n]] = e
+	}
+	return failed
+}
+
+// batchErrors maps the position of each failed write in a batch of size to its error.
+// An error that can not be tied to a write fails the whole batch
+func batchErrors(err error, size int) map[int]error {
+	failed := make(map[int]error)
+	if err == nil {
+		return failed
+	}
+	bulkErr, ok := err.(*mgo.BulkError)
+	if ok {
+		for _, c := range bulkErr.Cases() {
+			if c.Index < 0 || c.Index >= size {
+				ok = false
+				break
+			}
+			failed[c.Index] = c.Err
+		}
+	}
+	if !ok {
+		for n := 0; n < size; n++ {
+			failed[n] = err
+		}
+	}
+	return failed
+}
+
+// GetDomain satisfies the Store interface
+func (s *MongoStore) GetDomain(name string) (*Domain, error) {
+	session := s.session.Copy()
+	defer session.Close()
+	var sd storedDomain
+	err := session.DB(mongoDB).C(mongoCollection).Find(bson.M{"name": name}).One(&sd)
+	if err == mgo.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sd.domain()
+}
+
+// ListByNamespace satisfies the Store interface
+func (s *MongoStore) ListByNamespace(namespace string, offset, count int) (Domains, error) {
+	return s.list(bson.M{"namespace": namespace}, offset, count)
+}
+
+// ListByOwner satisfies the Store interface
+func (s *MongoStore) ListByOwner(address string) (Domains, error) {
+	return s.list(bson.M{"address": address}, 0, 0)
+}
+
+// list returns the domains matching query ordered by name
+func (s *MongoStore) list(query bson.M, offset, count int) (Domains, error) {
+	session := s.session.Copy()
+	defer session.Close()
+	q := session.DB(mongoDB).C(mongoCollection).Find(query).Sort("name").Skip(offset)
+	if count > 0 {
+		q = q.Limit(count)
+	}
+	var sds []storedDomain
+	if err := q.All(&sds); err != nil {
+		return nil, err
+	}
+	domains := make(Domains, 0, len(sds))
+	for _, sd := range sds {
+		d, err := sd.domain()
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, d)
+	}
+	return domains, nil
+}
+
+// LastIndexedBlock satisfies the Store interface
+func (s *MongoStore) LastIndexedBlock() (int, error) {
+	session := s.session.Copy()
+	defer session.Close()
+	var state indexerState
+	err := session.DB(mongoDB).C(stateCollection).FindId(stateID).One(&state)
+	if err != nil && err != mgo.ErrNotFound {
+		return 0, err
+	}
+	return state.LastBlock, nil
+}
+
+// SetLastIndexedBlock satisfies the Store interface
+func (s *MongoStore) SetLastIndexedBlock(block int) error {
+	session := s.session.Copy()
+	defer session.Close()
+	_, err := session.DB(mongoDB).C(stateCollection).UpsertId(stateID, indexerState{ID: stateID, LastBlock: block})
+	return err
+}
+
+// Close satisfies the Store interface
+func (s *MongoStore) Close() error {
+	s.session.Close()
+	return nil
+}
@@ -0,0 +1,16 @@
+package indexer
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestBatchErrors tests that write errors are tied to the names in a batch
+func TestBatchErrors(t *testing.T) {
+	if len(batchErrors(nil, 5)) != 0 {
+		t.Fail()
+	}
+	if len(batchErrors(errors.New("no reachable servers"), 5)) != 5 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,117 @@
+package indexer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+	"github.com/blockstack/blockstack.go/blockstack/blockstacktest"
+)
+
+// testDomain returns a *Domain for name owned by address with a zonefile
+func testDomain(name, address string) *Domain {
+	d := NewDomain(name)
+	d.AddZonefile(blockstacktest.FixtureZonefile(name, "https://example.com/"+name+"/profile.json"))
+	d.BlockchainRecord.Status = true
+	d.BlockchainRecord.Record.Name = name
+	d.BlockchainRecord.Record.Address = address
+	return d
+}
+
+// testStore runs the same checks against every Store
+func testStore(t *testing.T, s Store) {
+	defer s.Close()
+
+	withProfile := testDomain("muneeb.id", "17hEAjUUWp5wN9SEGYqxpdtjHKzWVkmHEo")
+	withProfile.Profile = &SOProfile{Token: "token"}
+	withProfile.VerifyProfile()
+	domains := Domains{
+		withProfile,
+		testDomain("judecn.id", "16EMaNw3pkn3v6f2BgnSSs53zAKH4Q8YJg"),
+		testDomain("ryan.id", "15GAGiT2j2F1EzZrvjk3B8vBCfwVEzQaZx"),
+		testDomain("jude.helloworld", "16EMaNw3pkn3v6f2BgnSSs53zAKH4Q8YJg"),
+	}
+	if failed := s.UpsertDomains(domains); len(failed) != 0 {
+		t.Fatal(failed)
+	}
+
+	d, err := s.GetDomain("muneeb.id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Zonefile.Raw != withProfile.Zonefile.Raw || d.Zonefile.Compliant != withProfile.Zonefile.Compliant || d.BlockchainRecord.Record.Address != "17hEAjUUWp5wN9SEGYqxpdtjHKzWVkmHEo" {
+		t.Errorf("unexpected domain %s", d.JSON())
+	}
+	if p, ok := d.Profile.(*SOProfile); !ok || p.Token != "token" {
+		t.Errorf("unexpected profile %v", d.Profile)
+	}
+	if d.Status != blockstack.StatusRegistered {
+		t.Errorf("expected status %s, got %s", blockstack.StatusRegistered, d.Status)
+	}
+	if d.Verification == nil || d.Verification.Verified || d.Verification.Error != withProfile.Verification.Error {
+		t.Errorf("unexpected verification %#v", d.Verification)
+	}
+	if _, err := s.GetDomain("doesnotexist.id"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	ns, err := s.ListByNamespace("id", 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 1 || ns[0].Name != "muneeb.id" {
+		t.Errorf("unexpected page %v", ns)
+	}
+
+	// Transferring a name moves it to the new owner
+	transferred := testDomain("judecn.id", "15GAGiT2j2F1EzZrvjk3B8vBCfwVEzQaZx")
+	if failed := s.UpsertDomains(Domains{transferred}); len(failed) != 0 {
+		t.Fatal(failed)
+	}
+	owned, err := s.ListByOwner("16EMaNw3pkn3v6f2BgnSSs53zAKH4Q8YJg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(owned) != 1 || owned[0].Name != "jude.helloworld" {
+		t.Errorf("unexpected names owned %v", owned)
+	}
+	owned, err = s.ListByOwner("15GAGiT2j2F1EzZrvjk3B8vBCfwVEzQaZx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(owned) != 2 || owned[0].Name != "judecn.id" || owned[1].Name != "ryan.id" {
+		t.Errorf("unexpected names owned %v", owned)
+	}
+
+	if block, err := s.LastIndexedBlock(); err != nil || block != 0 {
+		t.Errorf("expected block 0, got %d %v", block, err)
+	}
+	if err := s.SetLastIndexedBlock(blockstacktest.FixtureLastBlock); err != nil {
+		t.Fatal(err)
+	}
+	if block, err := s.LastIndexedBlock(); err != nil || block != blockstacktest.FixtureLastBlock {
+		t.Errorf("expected block %d, got %d %v", blockstacktest.FixtureLastBlock, block, err)
+	}
+}
+
+// TestMemoryStore tests the in-memory Store
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+// TestBoltStore tests the BoltDB Store
+func TestBoltStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "indexer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := NewBoltStore(filepath.Join(dir, "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, s)
+}
@@ -0,0 +1,303 @@
+package indexer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/miekg/dns"
+)
+
+// Subdomain holds what is known about a name registered off-chain in a TXT
+// record of its parent's zonefile, for example:
+//
+//	created_equal IN TXT "owner=1AYddAnfHbw6bPNvnsQFFrEuUdhMhf2XG9" "seqn=0" "parts=1" "zf0=JE9SSUdJTi..."
+//
+// The zfN parts are concatenated and base64 decoded to give the subdomain's
+// zonefile. Updates after the first carry a sig from the previous owner
+type Subdomain struct {
+	Parent string `json:"parent" bson:"parent"`
+	Owner  string `json:"owner" bson:"owner"`
+	Seqn   int    `json:"seqn" bson:"seqn"`
+}
+
+// Subdomains returns a *Domain for the latest valid update of every
+// subdomain in the TXT records of d's zonefile, see NewSubdomains
+func (d *Domain) Subdomains() Domains {
+	return d.NewSubdomains(nil)
+}
+
+// NewSubdomains returns a *Domain for the latest valid update of every
+// subdomain in the TXT records of d's zonefile that is newer than the one
+// current returns for its name. current returns nil for names it does not
+// know and may itself be nil.
+//
+// The first update of a subdomain must have seqn 0 and only the first such
+// record is taken. Every update after it must have the next seqn and be
+// signed by the owner before it. Records that can not be parsed or are not
+// valid are skipped
+func (d *Domain) NewSubdomains(current func(name string) *Subdomain) Domains {
+	if d.Zonefile == nil {
+		return nil
+	}
+	updates := make(map[string][]*subdomainUpdate)
+	for _, rr := range d.Zonefile.RRs {
+		txt, ok := rr.(*dns.TXT)
+		if !ok {
+			continue
+		}
+		u, err := parseSubdomain(d, txt)
+		if err != nil {
+			log.Println(logPrefix, "Skipping subdomain record in the zonefile of", d.Name, err)
+			continue
+		}
+		if u == nil {
+			continue
+		}
+		updates[u.Name] = append(updates[u.Name], u)
+	}
+
+	out := make(Domains, 0, len(updates))
+	for name, us := range updates {
+		sort.SliceStable(us, func(a, b int) bool { return us[a].Subdomain.Seqn < us[b].Subdomain.Seqn })
+		var prev *Subdomain
+		if current != nil {
+			prev = current(name)
+		}
+		var latest *Domain
+		for _, u := range us {
+			if err := u.follows(prev); err != nil {
+				if err != errStaleSubdomain {
+					log.Println(logPrefix, "Skipping subdomain record in the zonefile of", d.Name, err)
+				}
+				continue
+			}
+			prev = u.Subdomain
+			latest = u.Domain
+		}
+		if latest != nil {
+			out = append(out, latest)
+		}
+	}
+	sortDomains(out)
+	return out
+}
+
+// errStaleSubdomain is returned by follows for updates that are not newer
+// than the one before them
+var errStaleSubdomain = errors.New("subdomain update is not newer")
+
+// subdomainUpdate is a parsed subdomain record with the signature over it
+type subdomainUpdate struct {
+	*Domain
+	label    string
+	zonefile []byte
+	sig      string
+}
+
+// follows returns nil if u is a valid update after prev, prev is nil when
+// u would be the first update of the subdomain
+func (u *subdomainUpdate) follows(prev *Subdomain) error {
+	seqn := u.Subdomain.Seqn
+	switch {
+	case prev == nil && seqn == 0:
+		return nil
+	case prev == nil:
+		return fmt.Errorf("%s seqn %d has no update before it", u.Name, seqn)
+	case seqn <= prev.Seqn:
+		return errStaleSubdomain
+	case seqn != prev.Seqn+1:
+		return fmt.Errorf("%s seqn %d does not follow seqn %d", u.Name, seqn, prev.Seqn)
+	}
+	if err := verifySubdomainSig(prev.Owner, u.plaintext(), u.sig); err != nil {
+		return fmt.Errorf("%s seqn %d is not signed by %s: %v", u.Name, seqn, prev.Owner, err)
+	}
+	return nil
+}
+
+// plaintext returns the text the owner signs for u. It is the record
+// without its sig, with the zonefile split in parts of subdomainPartSize
+// and the fields joined by commas
+func (u *subdomainUpdate) plaintext() string {
+	enc := base64.StdEncoding.EncodeToString(u.zonefile)
+	var parts []string
+	for len(enc) > 0 {
+		n := subdomainPartSize
+		if len(enc) < n {
+			n = len(enc)
+		}
+		parts = append(parts, enc[:n])
+		enc = enc[n:]
+	}
+	fields := []string{
+		u.label,
+		"owner=" + u.Subdomain.Owner,
+		"seqn=" + strconv.Itoa(u.Subdomain.Seqn),
+		"parts=" + strconv.Itoa(len(parts)),
+	}
+	for n, part := range parts {
+		fields = append(fields, fmt.Sprintf("zf%d=%s", n, part))
+	}
+	return strings.Join(fields, ",")
+}
+
+// subdomainPartSize is the size of the base64 zonefile parts blockstack-core
+// signs subdomain records with
+const subdomainPartSize = 250
+
+// verifySubdomainSig checks that sig is a base64 encoded scriptSig pushing
+// a signature of the sha256 of plaintext and the public key of address.
+// Multisig owners are not supported
+func verifySubdomainSig(address, plaintext, sig string) error {
+	if sig == "" {
+		return errors.New("the record is not signed")
+	}
+	script, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("invalid sig: %v", err)
+	}
+	pushes, err := scriptPushes(script)
+	if err != nil {
+		return err
+	}
+	if len(pushes) != 2 {
+		return fmt.Errorf("unsupported scriptSig with %d items", len(pushes))
+	}
+	owner, _, err := base58.CheckDecode(address)
+	if err != nil {
+		return fmt.Errorf("invalid owner address: %v", err)
+	}
+	if !bytes.Equal(hash160(pushes[1]), owner) {
+		return errors.New("the public key is not the owner's")
+	}
+	key, err := btcec.ParsePubKey(pushes[1], btcec.S256())
+	if err != nil {
+		return fmt.Errorf("invalid public key: %v", err)
+	}
+	signature, err := parseSignature(pushes[0])
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(plaintext))
+	if !signature.Verify(digest[:], key) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// parseSignature parses a DER signature, with or without a trailing sighash
+// byte, or a 64 byte r and s
+func parseSignature(b []byte) (*btcec.Signature, error) {
+	if len(b) == 64 {
+		return &btcec.Signature{R: new(big.Int).SetBytes(b[:32]), S: new(big.Int).SetBytes(b[32:])}, nil
+	}
+	sig, err := btcec.ParseDERSignature(b, btcec.S256())
+	if err != nil && len(b) > 0 {
+		sig, err = btcec.ParseDERSignature(b[:len(b)-1], btcec.S256())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %v", err)
+	}
+	return sig, nil
+}
+
+// scriptPushes returns the data pushed by script, which may only hold pushes
+func scriptPushes(script []byte) ([][]byte, error) {
+	var pushes [][]byte
+	for len(script) > 0 {
+		op := script[0]
+		script = script[1:]
+		var n int
+		switch {
+		case op >= 0x01 && op <= 0x4b:
+			n = int(op)
+		case op == 0x4c && len(script) >= 1:
+			n, script = int(script[0]), script[1:]
+		case op == 0x4d && len(script) >= 2:
+			n, script = int(binary.LittleEndian.Uint16(script)), script[2:]
+		default:
+			return nil, fmt.Errorf("unsupported scriptSig opcode %#x", op)
+		}
+		if len(script) < n {
+			return nil, errors.New("truncated scriptSig")
+		}
+		pushes = append(pushes, script[:n])
+		script = script[n:]
+	}
+	return pushes, nil
+}
+
+// parseSubdomain returns the subdomain update of parent in txt, nil if txt
+// is not a subdomain record
+func parseSubdomain(parent *Domain, txt *dns.TXT) (*subdomainUpdate, error) {
+	fields := make(map[string]string)
+	for _, s := range txt.Txt {
+		kv := strings.SplitN(s, "=", 2)
+		if len(kv) == 2 {
+			fields[kv[0]] = kv[1]
+		}
+	}
+	if _, ok := fields["owner"]; !ok {
+		return nil, nil
+	}
+
+	name := strings.TrimSuffix(txt.Header().Name, ".")
+	label := strings.TrimSuffix(name, "."+parent.Name)
+	if label == name || label == "" || strings.Contains(label, ".") {
+		return nil, fmt.Errorf("%s is not a subdomain of %s", name, parent.Name)
+	}
+	if fields["owner"] == "" {
+		return nil, fmt.Errorf("%s has no owner", name)
+	}
+	seqn, err := strconv.Atoi(fields["seqn"])
+	if err != nil || seqn < 0 {
+		return nil, fmt.Errorf("%s has an invalid seqn %q", name, fields["seqn"])
+	}
+	parts, err := strconv.Atoi(fields["parts"])
+	if err != nil || parts < 0 {
+		return nil, fmt.Errorf("%s has an invalid number of parts %q", name, fields["parts"])
+	}
+
+	var encoded string
+	for n := 0; n < parts; n++ {
+		part, ok := fields[fmt.Sprintf("zf%d", n)]
+		if !ok {
+			return nil, fmt.Errorf("%s is missing zonefile part %d of %d", name, n, parts)
+		}
+		encoded += part
+	}
+	zonefile, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%s has an invalid zonefile: %v", name, err)
+	}
+
+	sub := NewDomain(name)
+	sub.Subdomain = &Subdomain{Parent: parent.Name, Owner: fields["owner"], Seqn: seqn}
+	sub.AddZonefile(string(zonefile))
+
+	// The record mirrors the parent's so subdomains can be served like on-chain names
+	sub.BlockchainRecord = blockstack.GetNameBlockchainRecordResult{
+		Status:    true,
+		Lastblock: parent.BlockchainRecord.Lastblock,
+	}
+	rec := &sub.BlockchainRecord.Record
+	rec.Name = name
+	rec.Address = sub.Subdomain.Owner
+	rec.NamespaceID = parent.BlockchainRecord.Record.NamespaceID
+	rec.ExpireBlock = parent.BlockchainRecord.Record.ExpireBlock
+	rec.Txid = parent.BlockchainRecord.Record.Txid
+	rec.BlockNumber = parent.BlockchainRecord.Record.BlockNumber
+	rec.ValueHash = blockstack.ZonefileHash(string(zonefile))
+	return &subdomainUpdate{Domain: sub, label: label, zonefile: zonefile, sig: fields["sig"]}, nil
+}
@@ -0,0 +1,252 @@
+package indexer
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blockstack/blockstack.go/blockstack"
+	"github.com/blockstack/blockstack.go/blockstack/blockstacktest"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/base58"
+)
+
+// subdomainRecord returns a subdomain TXT record with the zonefile split in two parts
+func subdomainRecord(label, owner string, seqn int, zonefile string) string {
+	enc := base64.StdEncoding.EncodeToString([]byte(zonefile))
+	half := len(enc) / 2
+	return fmt.Sprintf("%s TXT \"owner=%s\" \"seqn=%d\" \"parts=2\" \"zf0=%s\" \"zf1=%s\"\n", label, owner, seqn, enc[:half], enc[half:])
+}
+
+// parentZonefile returns a zonefile for name with the subdomain records
+func parentZonefile(name string, records ...string) string {
+	zf := blockstacktest.FixtureZonefile(name, "https://example.com/"+name+"/profile.json")
+	for _, r := range records {
+		zf += r
+	}
+	return zf
+}
+
+// subdomainKey returns a new private key and the address that owns it
+func subdomainKey(t *testing.T) (*btcec.PrivateKey, string) {
+	key, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, base58.CheckEncode(hash160(key.PubKey().SerializeCompressed()), 0)
+}
+
+// signedSubdomainRecord returns a subdomain TXT record signed by key
+func signedSubdomainRecord(t *testing.T, key *btcec.PrivateKey, label, owner string, seqn int, zonefile string) string {
+	u := &subdomainUpdate{Domain: NewDomain(label), label: label, zonefile: []byte(zonefile)}
+	u.Subdomain = &Subdomain{Owner: owner, Seqn: seqn}
+	digest := sha256.Sum256([]byte(u.plaintext()))
+	sig, err := key.Sign(digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	der := append(sig.Serialize(), 0x01)
+	pub := key.PubKey().SerializeCompressed()
+	script := append(append([]byte{byte(len(der))}, der...), append([]byte{byte(len(pub))}, pub...)...)
+	record := strings.TrimSuffix(subdomainRecord(label, owner, seqn, zonefile), "\n")
+	return fmt.Sprintf("%s \"sig=%s\"\n", record, base64.StdEncoding.EncodeToString(script))
+}
+
+// TestSubdomains tests that the subdomain records in a zonefile are parsed
+func TestSubdomains(t *testing.T) {
+	key, owner := subdomainKey(t)
+	_, other := subdomainKey(t)
+	old := blockstacktest.FixtureZonefile("created_equal", "https://example.com/old.json")
+	latest := blockstacktest.FixtureZonefile("created_equal", "https://example.com/latest.json")
+	d := NewDomain("self_evident_truth.id")
+	d.BlockchainRecord.Record.NamespaceID = "id"
+	d.AddZonefile(parentZonefile("self_evident_truth.id",
+		signedSubdomainRecord(t, key, "created_equal", owner, 1, latest),
+		subdomainRecord("created_equal", owner, 0, old),
+		subdomainRecord("created_equal", other, 0, old),
+		"missing_part TXT \"owner=1AYddAnfHbw6bPNvnsQFFrEuUdhMhf2XG9\" \"seqn=0\" \"parts=2\" \"zf0=JE9SSUdJTg==\"\n",
+		"notasubdomain TXT \"v=spf1 -all\"\n",
+	))
+
+	subs := d.Subdomains()
+	if len(subs) != 1 {
+		t.Fatalf("expected 1 subdomain, got %d", len(subs))
+	}
+	sub := subs[0]
+	if sub.Name != "created_equal.self_evident_truth.id" || sub.Subdomain.Seqn != 1 || sub.Subdomain.Parent != d.Name {
+		t.Errorf("unexpected subdomain %s", sub.JSON())
+	}
+	if sub.Zonefile.Raw != latest || sub.BlockchainRecord.Record.ValueHash != blockstack.ZonefileHash(latest) {
+		t.Errorf("unexpected zonefile %q", sub.Zonefile.Raw)
+	}
+	if !sub.BlockchainRecord.Status || sub.BlockchainRecord.Record.Address != owner || sub.BlockchainRecord.Record.NamespaceID != "id" {
+		t.Errorf("unexpected record %s", sub.BlockchainRecord.JSON())
+	}
+}
+
+// TestSubdomainsForged tests that updates not signed by the previous owner,
+// that skip a seqn or that have no seqn 0 before them are dropped
+func TestSubdomainsForged(t *testing.T) {
+	key, owner := subdomainKey(t)
+	thief, stolen := subdomainKey(t)
+	newKey, newOwner := subdomainKey(t)
+	zonefile := func(n int) string {
+		return blockstacktest.FixtureZonefile("created_equal", fmt.Sprintf("https://example.com/%d.json", n))
+	}
+
+	var tests = []struct {
+		name    string
+		current *Subdomain
+		records []string
+		seqn    int
+		owner   string
+	}{
+		{"forged seqn 1", nil, []string{
+			subdomainRecord("created_equal", owner, 0, zonefile(0)),
+			signedSubdomainRecord(t, thief, "created_equal", stolen, 1, zonefile(1)),
+		}, 0, owner},
+		{"forged higher seqn", nil, []string{
+			subdomainRecord("created_equal", owner, 0, zonefile(0)),
+			signedSubdomainRecord(t, key, "created_equal", owner, 1, zonefile(1)),
+			signedSubdomainRecord(t, thief, "created_equal", stolen, 2, zonefile(2)),
+			signedSubdomainRecord(t, thief, "created_equal", stolen, 3, zonefile(3)),
+		}, 1, owner},
+		{"unsigned seqn 1", nil, []string{
+			subdomainRecord("created_equal", owner, 0, zonefile(0)),
+			subdomainRecord("created_equal", stolen, 1, zonefile(1)),
+		}, 0, owner},
+		{"skipped seqn", nil, []string{
+			subdomainRecord("created_equal", owner, 0, zonefile(0)),
+			signedSubdomainRecord(t, key, "created_equal", owner, 2, zonefile(2)),
+		}, 0, owner},
+		{"signed by the old owner", nil, []string{
+			subdomainRecord("created_equal", owner, 0, zonefile(0)),
+			signedSubdomainRecord(t, key, "created_equal", newOwner, 1, zonefile(1)),
+			signedSubdomainRecord(t, key, "created_equal", owner, 2, zonefile(2)),
+		}, 1, newOwner},
+		{"transferred", nil, []string{
+			subdomainRecord("created_equal", owner, 0, zonefile(0)),
+			signedSubdomainRecord(t, key, "created_equal", newOwner, 1, zonefile(1)),
+			signedSubdomainRecord(t, newKey, "created_equal", newOwner, 2, zonefile(2)),
+		}, 2, newOwner},
+		{"no seqn 0", nil, []string{
+			signedSubdomainRecord(t, thief, "created_equal", stolen, 5, zonefile(5)),
+		}, -1, ""},
+		{"seqn 0 over the current", &Subdomain{Owner: owner, Seqn: 1}, []string{
+			subdomainRecord("created_equal", stolen, 0, zonefile(0)),
+		}, -1, ""},
+		{"forged after the current", &Subdomain{Owner: owner, Seqn: 1}, []string{
+			signedSubdomainRecord(t, thief, "created_equal", stolen, 2, zonefile(2)),
+		}, -1, ""},
+		{"signed after the current", &Subdomain{Owner: owner, Seqn: 1}, []string{
+			signedSubdomainRecord(t, key, "created_equal", owner, 2, zonefile(2)),
+		}, 2, owner},
+	}
+	for _, tt := range tests {
+		d := NewDomain("self_evident_truth.id")
+		d.AddZonefile(parentZonefile("self_evident_truth.id", tt.records...))
+		subs := d.NewSubdomains(func(name string) *Subdomain { return tt.current })
+		if tt.seqn < 0 {
+			if len(subs) != 0 {
+				t.Errorf("%s: expected no update, got %s", tt.name, subs[0].JSON())
+			}
+			continue
+		}
+		if len(subs) != 1 {
+			t.Errorf("%s: expected 1 subdomain, got %d", tt.name, len(subs))
+			continue
+		}
+		if sub := subs[0]; sub.Subdomain.Seqn != tt.seqn || sub.Subdomain.Owner != tt.owner || sub.Zonefile.Raw != zonefile(tt.seqn) {
+			t.Errorf("%s: expected seqn %d owned by %s, got %s", tt.name, tt.seqn, tt.owner, sub.JSON())
+		}
+	}
+}
+
+// TestVerifySubdomainSig tests the signature formats blockstack-core may send
+func TestVerifySubdomainSig(t *testing.T) {
+	key, owner := subdomainKey(t)
+	digest := sha256.Sum256([]byte("created_equal,owner=" + owner))
+	sig, err := key.Sign(digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := key.PubKey().SerializeCompressed()
+	push := func(b []byte) []byte { return append([]byte{byte(len(b))}, b...) }
+	pad := func(b []byte) []byte { return append(make([]byte, 32-len(b)), b...) }
+	raw := append(pad(sig.R.Bytes()), pad(sig.S.Bytes())...)
+
+	var tests = []struct {
+		name   string
+		script []byte
+		valid  bool
+	}{
+		{"der", append(push(sig.Serialize()), push(pub)...), true},
+		{"der with sighash", append(push(append(sig.Serialize(), 0x01)), push(pub)...), true},
+		{"r and s", append(push(raw), push(pub)...), true},
+		{"uncompressed key", append(push(sig.Serialize()), push(key.PubKey().SerializeUncompressed())...), false},
+		{"no key", push(sig.Serialize()), false},
+		{"multisig", append([]byte{0x00}, append(push(sig.Serialize()), push(pub)...)...), false},
+		{"truncated", append(push(sig.Serialize()), 0x21, 0x02), false},
+	}
+	for _, tt := range tests {
+		err := verifySubdomainSig(owner, "created_equal,owner="+owner, base64.StdEncoding.EncodeToString(tt.script))
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid %v, got %v", tt.name, tt.valid, err)
+		}
+	}
+	if err := verifySubdomainSig(owner, "created_equal,owner="+owner+"x", base64.StdEncoding.EncodeToString(append(push(sig.Serialize()), push(pub)...))); err == nil {
+		t.Errorf("expected a signature over other text to be invalid")
+	}
+}
+
+// TestSubdomainsIndexed tests that subdomains are written to the Store without
+// replacing one with a higher seqn
+func TestSubdomainsIndexed(t *testing.T) {
+	core := blockstacktest.NewCore()
+	defer core.Close()
+	core.AddName("self_evident_truth.id", "1Bv2vJMqBLrm7pRGTsMqDeoKb8bf6fsBPe", 470000)
+	core.UpdateZonefile("self_evident_truth.id", 470001, parentZonefile("self_evident_truth.id",
+		subdomainRecord("created_equal", "1AYddAnfHbw6bPNvnsQFFrEuUdhMhf2XG9", 0, blockstacktest.FixtureZonefile("created_equal", "https://example.com/0.json")),
+		subdomainRecord("pursuit", "1AYddAnfHbw6bPNvnsQFFrEuUdhMhf2XG9", 0, blockstacktest.FixtureZonefile("pursuit", "https://example.com/0.json")),
+	))
+
+	idx := testIndexer(core)
+	idx.Config.IndexMethod = "byName"
+	idx.Config.ClientUpdateInterval = 60
+	idx.Config.DBFlushInterval = 10 * time.Millisecond
+	idx.Config.NamePageWorkers, idx.Config.ResolveWorkers, idx.Config.DBWorkers = 2, 2, 2
+
+	newer := NewDomain("pursuit.self_evident_truth.id")
+	newer.Subdomain = &Subdomain{Parent: "self_evident_truth.id", Owner: "1AYddAnfHbw6bPNvnsQFFrEuUdhMhf2XG9", Seqn: 3}
+	newer.BlockchainRecord.Record.Address = newer.Subdomain.Owner
+	idx.Store().UpsertDomains(Domains{newer})
+	idx.Start()
+
+	d, err := idx.Store().GetDomain("created_equal.self_evident_truth.id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Subdomain == nil || d.Subdomain.Parent != "self_evident_truth.id" || d.GetURI() == nil {
+		t.Errorf("unexpected subdomain %s", d.JSON())
+	}
+	if d, err := idx.Store().GetDomain("pursuit.self_evident_truth.id"); err != nil || d.Subdomain.Seqn != 3 {
+		t.Errorf("subdomain with a higher seqn was replaced: %v", err)
+	}
+
+	owned, err := idx.Store().ListByOwner("1AYddAnfHbw6bPNvnsQFFrEuUdhMhf2XG9")
+	if err != nil || len(owned) != 2 {
+		t.Errorf("expected 2 subdomains owned, got %d %v", len(owned), err)
+	}
+	names, err := idx.Store().ListByNamespace("id", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range names {
+		if d.Subdomain != nil {
+			t.Errorf("subdomain %s listed in the namespace", d.Name)
+		}
+	}
+}
@@ -1,70 +1,229 @@
 package indexer
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"math/big"
+	"strconv"
+	"strings"
 	"time"
 
-	"gopkg.in/square/go-jose.v1"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/base58"
+	"golang.org/x/crypto/ripemd160"
 )
 
-func NewDecodedProfileToken(pt string) *DecodedProfileToken {
-	object, err := jose.ParseSigned(pt)
-	if err != nil {
-		log.Println(err)
+// ProfileVerification is the outcome of checking a profile against the owner of its name
+type ProfileVerification struct {
+	Verified bool      `json:"verified" bson:"verified"`
+	Error    string    `json:"error,omitempty" bson:"error,omitempty"`
+	Checked  time.Time `json:"checked" bson:"checked"`
+}
+
+// VerifyProfile checks the profile of d against the owner address of the name
+// and records the outcome in d.Verification
+func (d *Domain) VerifyProfile() {
+	if d.Profile == nil {
+		d.Verification = nil
+		return
 	}
-	dpt := &DecodedProfileToken{}
-	err = json.Unmarshal([]byte(object.FullSerialize()), dpt)
-	if err != nil {
-		log.Println(err)
+	v := &ProfileVerification{Checked: time.Now()}
+	if err := d.Profile.Verify(d.BlockchainRecord.Record.Address); err != nil {
+		v.Error = err.Error()
+	} else {
+		v.Verified = true
 	}
-	return dpt
+	d.Verification = v
 }
 
+// NewDecodedProfileToken splits a compact serialized JWT into its parts
+func NewDecodedProfileToken(pt string) *DecodedProfileToken {
+	parts := strings.Split(pt, ".")
+	if len(parts) != 3 {
+		log.Println("[validation] Profile token has", len(parts), "parts, expected 3")
+		return &DecodedProfileToken{}
+	}
+	return &DecodedProfileToken{Protected: parts[0], Payload: parts[1], Signature: parts[2]}
+}
+
+// DecodedProfileToken holds the base64url encoded parts of a profile token
 type DecodedProfileToken struct {
 	Payload   string `json:"payload"`
 	Protected string `json:"protected"`
 	Signature string `json:"signature"`
 }
 
+// DecodedPayload returns the payload of the token
 func (dpt *DecodedProfileToken) DecodedPayload() *DecodedProfileTokenPayload {
-	data, err := base64.RawURLEncoding.DecodeString(dpt.Payload)
+	prof := &DecodedProfileTokenPayload{}
+	if err := decodeSegment(dpt.Payload, prof); err != nil {
+		log.Println("[validation]", err)
+	}
+	return prof
+}
+
+// Verify checks the token is signed with ES256K by the hex encoded publicKey
+func (dpt *DecodedProfileToken) Verify(publicKey string) error {
+	var header Header
+	if err := decodeSegment(dpt.Protected, &header); err != nil {
+		return err
+	}
+	if header.Alg != "ES256K" {
+		return fmt.Errorf("unsupported token algorithm %q", header.Alg)
+	}
+	key, err := parsePublicKey(publicKey)
 	if err != nil {
-		log.Println(err)
+		return err
 	}
-	prof := &DecodedProfileTokenPayload{}
-	err = json.Unmarshal(data, prof)
+	sig, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(dpt.Signature, "="))
 	if err != nil {
-		log.Println(err)
+		return fmt.Errorf("invalid token signature: %v", err)
 	}
-	return prof
+	var r, s *big.Int
+	if len(sig) == 64 {
+		r, s = new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	} else {
+		der, err := btcec.ParseDERSignature(sig, btcec.S256())
+		if err != nil {
+			return fmt.Errorf("invalid token signature: %v", err)
+		}
+		r, s = der.R, der.S
+	}
+	hash := sha256.Sum256([]byte(dpt.Protected + "." + dpt.Payload))
+	if !ecdsa.Verify(key.ToECDSA(), hash[:], r, s) {
+		return errors.New("token signature does not match the issuer public key")
+	}
+	return nil
 }
 
-func (so SOProfile) Validate() bool {
-	dptp := NewDecodedProfileToken(so.Token).DecodedPayload()
+// Verify checks the token signature, that the signing key belongs to the
+// owner address and that the token has not expired
+func (so SOProfile) Verify(owner string) error {
+	dpt := NewDecodedProfileToken(so.Token)
+	dptp := dpt.DecodedPayload()
 	if dptp.Issuer.PublicKey != so.DecodedToken.Payload.Issuer.PublicKey {
-		log.Println("[validation] New Profile failed validation for issuer", dptp.Issuer.PublicKey, so.DecodedToken.Payload.Issuer.PublicKey)
-		return false
+		return fmt.Errorf("decoded token issuer %s does not match the token", so.DecodedToken.Payload.Issuer.PublicKey)
 	}
 	if dptp.Subject.PublicKey != so.DecodedToken.Payload.Subject.PublicKey {
-		log.Println("[validation] New Profile failed validation for subject", dptp.Subject.PublicKey, so.DecodedToken.Payload.Subject.PublicKey)
-		return false
+		return fmt.Errorf("decoded token subject %s does not match the token", so.DecodedToken.Payload.Subject.PublicKey)
+	}
+	if err := dpt.Verify(dptp.Issuer.PublicKey); err != nil {
+		return err
 	}
-	return true
+	if !ownsKey(owner, dptp.Issuer.PublicKey) {
+		return fmt.Errorf("token issuer %s is not the owner %s", dptp.Issuer.PublicKey, owner)
+	}
+	expires, err := dptp.expiry()
+	if err != nil {
+		return err
+	}
+	if !expires.IsZero() && time.Now().After(expires) {
+		return fmt.Errorf("token expired at %v", expires)
+	}
+	return nil
 }
 
-func (lp LegacyProfile) Validate() bool {
-	return true
+// Verify is always successful for legacy profiles, they are kept
+// in the zonefile whose hash is on the blockchain
+func (lp LegacyProfile) Verify(owner string) error {
+	return nil
 }
 
+// DecodedProfileTokenPayload is the payload of a profile token. Older tokens
+// have issuedAt and expiresAt, newer ones iat and exp
 type DecodedProfileTokenPayload struct {
 	IssuedAt  string `json:"issuedAt,omitempty"`
 	Claim     Claim
 	ExpiresAt string `json:"expiresAt,omitempty"`
 	Issuer    PublicKey
 	Subject   PublicKey
-	Jti       string    `json:"jti,omitempty"`
-	Iat       time.Time `json:"iat,omitempty"`
-	Exp       time.Time `json:"exp,omitempty"`
+	Jti       string          `json:"jti,omitempty"`
+	Iat       json.RawMessage `json:"iat,omitempty"`
+	Exp       json.RawMessage `json:"exp,omitempty"`
+}
+
+// expiry returns when the token expires, the zero time if it does not
+func (p *DecodedProfileTokenPayload) expiry() (time.Time, error) {
+	if p.ExpiresAt != "" {
+		return parseTokenTime(p.ExpiresAt)
+	}
+	if len(p.Exp) == 0 {
+		return time.Time{}, nil
+	}
+	var exp string
+	if err := json.Unmarshal(p.Exp, &exp); err == nil {
+		return parseTokenTime(exp)
+	}
+	return parseTokenTime(string(p.Exp))
+}
+
+// parseTokenTime parses an RFC3339 date or a number of seconds since the epoch
+func parseTokenTime(t string) (time.Time, error) {
+	if secs, err := strconv.ParseFloat(t, 64); err == nil {
+		return time.Unix(int64(secs), 0), nil
+	}
+	parsed, err := time.Parse(time.RFC3339, t)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid token time %q", t)
+	}
+	return parsed, nil
+}
+
+// decodeSegment unmarshals a base64url encoded JSON segment of a token into v
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(seg, "="))
+	if err != nil {
+		return fmt.Errorf("invalid token segment: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid token segment: %v", err)
+	}
+	return nil
+}
+
+// parsePublicKey parses a hex encoded compressed or uncompressed secp256k1 public key
+func parsePublicKey(publicKey string) (*btcec.PublicKey, error) {
+	byt, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key %q: %v", publicKey, err)
+	}
+	key, err := btcec.ParsePubKey(byt, btcec.S256())
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key %q: %v", publicKey, err)
+	}
+	return key, nil
+}
+
+// ownsKey reports whether the bitcoin address is the hash of publicKey
+// in either its compressed or uncompressed form
+func ownsKey(address, publicKey string) bool {
+	hash, _, err := base58.CheckDecode(address)
+	if err != nil {
+		return false
+	}
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return false
+	}
+	for _, ser := range [][]byte{key.SerializeCompressed(), key.SerializeUncompressed()} {
+		if bytes.Equal(hash160(ser), hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// hash160 returns RIPEMD160(SHA256(b))
+func hash160(b []byte) []byte {
+	sha := sha256.Sum256(b)
+	h := ripemd160.New()
+	h.Write(sha[:])
+	return h.Sum(nil)
 }
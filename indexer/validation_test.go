@@ -0,0 +1,99 @@
+package indexer
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/base58"
+)
+
+// signedProfile returns an SOProfile with a token signed by key using alg
+func signedProfile(t *testing.T, key *btcec.PrivateKey, alg string, expires time.Time) *SOProfile {
+	pub := hex.EncodeToString(key.PubKey().SerializeCompressed())
+	payload := Payload{
+		IssuedAt:  time.Now().Format(time.RFC3339),
+		ExpiresAt: expires.Format(time.RFC3339),
+		Issuer:    PublicKey{PublicKey: pub},
+		Subject:   PublicKey{PublicKey: pub},
+	}
+	header, _ := json.Marshal(Header{Typ: "JWT", Alg: alg})
+	body, _ := json.Marshal(payload)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+
+	hash := sha256.Sum256([]byte(signingInput))
+	sig, err := key.Sign(hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := make([]byte, 64)
+	r, s := sig.R.Bytes(), sig.S.Bytes()
+	copy(raw[32-len(r):32], r)
+	copy(raw[64-len(s):], s)
+	return &SOProfile{
+		Token:        signingInput + "." + base64.RawURLEncoding.EncodeToString(raw),
+		DecodedToken: DecodedToken{Payload: payload, Header: Header{Typ: "JWT", Alg: alg}},
+	}
+}
+
+// addressOf returns the bitcoin address of the compressed public key
+func addressOf(key *btcec.PrivateKey) string {
+	return base58.CheckEncode(hash160(key.PubKey().SerializeCompressed()), 0)
+}
+
+// TestVerifyProfile tests the signature, owner and expiry checks on profile tokens
+func TestVerifyProfile(t *testing.T) {
+	key, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := addressOf(key)
+	valid := signedProfile(t, key, "ES256K", time.Now().Add(time.Hour))
+
+	if err := valid.Verify(owner); err != nil {
+		t.Errorf("expected a valid profile, got %v", err)
+	}
+	if err := valid.Verify(addressOf(other)); err == nil {
+		t.Errorf("expected a profile signed by another key to fail")
+	}
+
+	tampered := *valid
+	parts := strings.Split(tampered.Token, ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"issuer":{"publicKey":"` + valid.DecodedToken.Payload.Issuer.PublicKey + `"},"subject":{"publicKey":"` + valid.DecodedToken.Payload.Subject.PublicKey + `"}}`))
+	tampered.Token = strings.Join(parts, ".")
+	if err := tampered.Verify(owner); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("expected a tampered payload to fail, got %v", err)
+	}
+
+	expired := signedProfile(t, key, "ES256K", time.Now().Add(-time.Hour))
+	if err := expired.Verify(owner); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("expected an expired profile to fail, got %v", err)
+	}
+
+	badAlg := signedProfile(t, key, "none", time.Now().Add(time.Hour))
+	if err := badAlg.Verify(owner); err == nil || !strings.Contains(err.Error(), "algorithm") {
+		t.Errorf("expected an unsupported algorithm to fail, got %v", err)
+	}
+
+	d := NewDomain("muneeb.id")
+	d.BlockchainRecord.Record.Address = owner
+	d.Profile = valid
+	d.VerifyProfile()
+	if d.Verification == nil || !d.Verification.Verified || d.Verification.Error != "" {
+		t.Errorf("unexpected verification %#v", d.Verification)
+	}
+	d.Profile = expired
+	d.VerifyProfile()
+	if d.Verification == nil || d.Verification.Verified || d.Verification.Error == "" {
+		t.Errorf("unexpected verification %#v", d.Verification)
+	}
+}
@@ -1,26 +1,37 @@
 package indexer
 
 import (
-	"fmt"
+	"encoding/json"
 
+	"github.com/blockstack/blockstack.go/zonefile"
 	"github.com/miekg/dns"
 )
 
-// Zonefile models a Zonefile
+// Zonefile models a Zonefile. Raw is kept as it was fetched since its hash is
+// the value_hash of the name
 type Zonefile struct {
 	Raw string   `json:"raw"`
 	RRs []dns.RR `json:"RRs"`
 
 	Compliant bool
+	// Error is why Raw could not be parsed when it is not Compliant
+	Error *zonefile.ParseError `json:"error,omitempty"`
+
+	parsed *zonefile.Zonefile
 }
 
-// This is a JSON representation of a zonefile for out output
-// TODO: Do I need this?
-type zonefileOut struct {
-	Origin string   `json:"$origin"`
-	TTL    string   `json:"$ttl"`
-	URI    []dns.RR `json:"URI"`
-	TXT    []dns.RR `json:"TXT"`
+// newZonefile parses raw, a zonefile that does not parse is kept with the error
+func newZonefile(raw string) *Zonefile {
+	zf := &Zonefile{Raw: raw, RRs: make([]dns.RR, 0)}
+	parsed, err := zonefile.Parse(raw)
+	if err != nil {
+		zf.Error = err.(*zonefile.ParseError)
+		return zf
+	}
+	zf.parsed = parsed
+	zf.RRs = parsed.Records
+	zf.Compliant = true
+	return zf
 }
 
 // GetURI returns the first URI with a Target starting with http
@@ -38,11 +49,15 @@ func (zf *Zonefile) GetURI() *dns.URI {
 	return URI
 }
 
-// JSON representation of a Zonefile
+// JSON representation of a Zonefile in the format of blockstack-core. Legacy
+// zonefiles that hold a JSON profile are returned as is
 func (zf *Zonefile) JSON() []byte {
-	var byt []byte
-	for _, rr := range zf.RRs {
-		fmt.Printf("%#v\n", rr)
+	if !zf.Compliant {
+		if json.Valid([]byte(zf.Raw)) {
+			return []byte(zf.Raw)
+		}
+		byt, _ := json.Marshal(zf.Raw)
+		return byt
 	}
-	return byt
+	return zf.parsed.JSON()
 }
@@ -0,0 +1,27 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/blockstack/blockstack.go/blockstack/blockstacktest"
+)
+
+// TestZonefileJSON tests zonefiles are output in the format of blockstack-core
+func TestZonefileJSON(t *testing.T) {
+	d := NewDomain("muneeb.id")
+	d.AddZonefile(blockstacktest.FixtureZonefile("muneeb.id", "https://example.com/profile.json"))
+	expected := `{"$origin":"muneeb.id","$ttl":3600,"uri":[{"name":"_http._tcp","priority":10,"weight":1,"target":"https://example.com/profile.json"}]}`
+	if out := string(d.Zonefile.JSON()); out != expected {
+		t.Errorf("expected %s, got %s", expected, out)
+	}
+
+	legacy := `{"v": "0.2", "bio": "Co-founder", "twitter": {"username": "muneeb", "proof": {"url": "https://twitter.com/muneeb/status/1"}}, "account": [{"service": "github", "identifier": "muneeb-ali"}]}`
+	d.AddZonefile(legacy)
+	if out := string(d.Zonefile.JSON()); out != legacy {
+		t.Errorf("expected the legacy zonefile as is, got %s", out)
+	}
+	expected = `{"@type":"Person","account":[{"@type":"Account","service":"github","proofType":"","identifier":"muneeb-ali","proofUrl":""},{"@type":"Account","service":"twitter","proofType":"http","identifier":"muneeb","proofUrl":"https://twitter.com/muneeb/status/1"}],"description":"Co-founder"}`
+	if d.Profile == nil || string(d.Profile.Person()) != expected {
+		t.Errorf("unexpected person %v", d.Profile)
+	}
+}
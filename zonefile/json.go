@@ -0,0 +1,228 @@
+package zonefile
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// zonefileJSON is the shape of blockstack's zone-file library. Names are
+// relative to $origin and a ttl is only set where it is not $ttl
+type zonefileJSON struct {
+	Origin string      `json:"$origin,omitempty"`
+	TTL    uint32      `json:"$ttl,omitempty"`
+	SOA    *soaJSON    `json:"soa,omitempty"`
+	NS     []hostJSON  `json:"ns,omitempty"`
+	A      []ipJSON    `json:"a,omitempty"`
+	AAAA   []ipJSON    `json:"aaaa,omitempty"`
+	CNAME  []cnameJSON `json:"cname,omitempty"`
+	MX     []mxJSON    `json:"mx,omitempty"`
+	PTR    []hostJSON  `json:"ptr,omitempty"`
+	TXT    []txtJSON   `json:"txt,omitempty"`
+	SRV    []srvJSON   `json:"srv,omitempty"`
+	SPF    []spfJSON   `json:"spf,omitempty"`
+	URI    []uriJSON   `json:"uri,omitempty"`
+}
+
+type soaJSON struct {
+	Name    string `json:"name"`
+	TTL     uint32 `json:"ttl,omitempty"`
+	MName   string `json:"mname"`
+	RName   string `json:"rname"`
+	Serial  uint32 `json:"serial"`
+	Refresh uint32 `json:"refresh"`
+	Retry   uint32 `json:"retry"`
+	Expire  uint32 `json:"expire"`
+	Minimum uint32 `json:"minimum"`
+}
+
+type hostJSON struct {
+	Name string `json:"name"`
+	TTL  uint32 `json:"ttl,omitempty"`
+	Host string `json:"host"`
+}
+
+type ipJSON struct {
+	Name string `json:"name"`
+	TTL  uint32 `json:"ttl,omitempty"`
+	IP   string `json:"ip"`
+}
+
+type cnameJSON struct {
+	Name  string `json:"name"`
+	TTL   uint32 `json:"ttl,omitempty"`
+	Alias string `json:"alias"`
+}
+
+type mxJSON struct {
+	Name       string `json:"name"`
+	TTL        uint32 `json:"ttl,omitempty"`
+	Preference uint16 `json:"preference"`
+	Host       string `json:"host"`
+}
+
+type txtJSON struct {
+	Name string   `json:"name"`
+	TTL  uint32   `json:"ttl,omitempty"`
+	TXT  txtValue `json:"txt"`
+}
+
+type srvJSON struct {
+	Name     string `json:"name"`
+	TTL      uint32 `json:"ttl,omitempty"`
+	Target   string `json:"target"`
+	Priority uint16 `json:"priority"`
+	Weight   uint16 `json:"weight"`
+	Port     uint16 `json:"port"`
+}
+
+type spfJSON struct {
+	Name string `json:"name"`
+	TTL  uint32 `json:"ttl,omitempty"`
+	Data string `json:"data"`
+}
+
+type uriJSON struct {
+	Name     string `json:"name"`
+	TTL      uint32 `json:"ttl,omitempty"`
+	Priority uint16 `json:"priority"`
+	Weight   uint16 `json:"weight"`
+	Target   string `json:"target"`
+}
+
+// txtValue is a single string when a TXT record has one, a list otherwise
+type txtValue []string
+
+func (t txtValue) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *txtValue) UnmarshalJSON(byt []byte) error {
+	var s string
+	if err := json.Unmarshal(byt, &s); err == nil {
+		*t = txtValue{s}
+		return nil
+	}
+	return json.Unmarshal(byt, (*[]string)(t))
+}
+
+// JSON returns zf in the JSON format of blockstack's zone-file library.
+// Record types the library does not know are left out
+func (zf *Zonefile) JSON() []byte {
+	byt, err := json.Marshal(zf)
+	if err != nil {
+		panic(err)
+	}
+	return byt
+}
+
+// MarshalJSON satisfies json.Marshaler
+func (zf *Zonefile) MarshalJSON() ([]byte, error) {
+	out := zonefileJSON{Origin: zf.Origin, TTL: zf.TTL}
+	for _, rr := range zf.Records {
+		name := zf.relative(rr.Header().Name)
+		var ttl uint32
+		if rr.Header().Ttl != zf.ttl() {
+			ttl = rr.Header().Ttl
+		}
+		switch r := rr.(type) {
+		case *dns.SOA:
+			out.SOA = &soaJSON{Name: name, TTL: ttl, MName: r.Ns, RName: r.Mbox, Serial: r.Serial, Refresh: r.Refresh, Retry: r.Retry, Expire: r.Expire, Minimum: r.Minttl}
+		case *dns.NS:
+			out.NS = append(out.NS, hostJSON{Name: name, TTL: ttl, Host: r.Ns})
+		case *dns.A:
+			out.A = append(out.A, ipJSON{Name: name, TTL: ttl, IP: r.A.String()})
+		case *dns.AAAA:
+			out.AAAA = append(out.AAAA, ipJSON{Name: name, TTL: ttl, IP: r.AAAA.String()})
+		case *dns.CNAME:
+			out.CNAME = append(out.CNAME, cnameJSON{Name: name, TTL: ttl, Alias: r.Target})
+		case *dns.MX:
+			out.MX = append(out.MX, mxJSON{Name: name, TTL: ttl, Preference: r.Preference, Host: r.Mx})
+		case *dns.PTR:
+			out.PTR = append(out.PTR, hostJSON{Name: name, TTL: ttl, Host: r.Ptr})
+		case *dns.TXT:
+			out.TXT = append(out.TXT, txtJSON{Name: name, TTL: ttl, TXT: r.Txt})
+		case *dns.SRV:
+			out.SRV = append(out.SRV, srvJSON{Name: name, TTL: ttl, Target: r.Target, Priority: r.Priority, Weight: r.Weight, Port: r.Port})
+		case *dns.SPF:
+			out.SPF = append(out.SPF, spfJSON{Name: name, TTL: ttl, Data: strings.Join(r.Txt, "")})
+		case *dns.URI:
+			out.URI = append(out.URI, uriJSON{Name: name, TTL: ttl, Priority: r.Priority, Weight: r.Weight, Target: r.Target})
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON satisfies json.Unmarshaler. Records are ordered by type as
+// the library's JSON does not keep the order of the zone file
+func (zf *Zonefile) UnmarshalJSON(byt []byte) error {
+	var in zonefileJSON
+	if err := json.Unmarshal(byt, &in); err != nil {
+		return err
+	}
+	*zf = Zonefile{Origin: in.Origin, TTL: in.TTL}
+	hdr := func(name string, rrtype uint16, ttl uint32) dns.RR_Header {
+		if ttl == 0 {
+			ttl = zf.ttl()
+		}
+		return dns.RR_Header{Name: zf.absolute(name), Rrtype: rrtype, Class: dns.ClassINET, Ttl: ttl}
+	}
+	ip := func(s string) (net.IP, error) {
+		if addr := net.ParseIP(s); addr != nil {
+			return addr, nil
+		}
+		return nil, fmt.Errorf("zonefile: invalid ip %q", s)
+	}
+
+	if r := in.SOA; r != nil {
+		zf.Records = append(zf.Records, &dns.SOA{Hdr: hdr(r.Name, dns.TypeSOA, r.TTL), Ns: r.MName, Mbox: r.RName, Serial: r.Serial, Refresh: r.Refresh, Retry: r.Retry, Expire: r.Expire, Minttl: r.Minimum})
+	}
+	for _, r := range in.NS {
+		zf.Records = append(zf.Records, &dns.NS{Hdr: hdr(r.Name, dns.TypeNS, r.TTL), Ns: r.Host})
+	}
+	for _, r := range in.A {
+		addr, err := ip(r.IP)
+		if err != nil {
+			return err
+		}
+		if addr.To4() == nil {
+			return fmt.Errorf("zonefile: %q is not an IPv4 address", r.IP)
+		}
+		zf.Records = append(zf.Records, &dns.A{Hdr: hdr(r.Name, dns.TypeA, r.TTL), A: addr.To4()})
+	}
+	for _, r := range in.AAAA {
+		addr, err := ip(r.IP)
+		if err != nil {
+			return err
+		}
+		zf.Records = append(zf.Records, &dns.AAAA{Hdr: hdr(r.Name, dns.TypeAAAA, r.TTL), AAAA: addr})
+	}
+	for _, r := range in.CNAME {
+		zf.Records = append(zf.Records, &dns.CNAME{Hdr: hdr(r.Name, dns.TypeCNAME, r.TTL), Target: r.Alias})
+	}
+	for _, r := range in.MX {
+		zf.Records = append(zf.Records, &dns.MX{Hdr: hdr(r.Name, dns.TypeMX, r.TTL), Preference: r.Preference, Mx: r.Host})
+	}
+	for _, r := range in.PTR {
+		zf.Records = append(zf.Records, &dns.PTR{Hdr: hdr(r.Name, dns.TypePTR, r.TTL), Ptr: r.Host})
+	}
+	for _, r := range in.TXT {
+		zf.Records = append(zf.Records, &dns.TXT{Hdr: hdr(r.Name, dns.TypeTXT, r.TTL), Txt: r.TXT})
+	}
+	for _, r := range in.SRV {
+		zf.Records = append(zf.Records, &dns.SRV{Hdr: hdr(r.Name, dns.TypeSRV, r.TTL), Priority: r.Priority, Weight: r.Weight, Port: r.Port, Target: r.Target})
+	}
+	for _, r := range in.SPF {
+		zf.Records = append(zf.Records, &dns.SPF{Hdr: hdr(r.Name, dns.TypeSPF, r.TTL), Txt: []string{r.Data}})
+	}
+	for _, r := range in.URI {
+		zf.Records = append(zf.Records, &dns.URI{Hdr: hdr(r.Name, dns.TypeURI, r.TTL), Priority: r.Priority, Weight: r.Weight, Target: r.Target})
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+// Package zonefile parses, serializes and converts to JSON the zone files
+// Blockstack names point to. A zone file like:
+//
+//	$ORIGIN muneeb.id
+//	$TTL 3600
+//	_http._tcp IN URI 10 1 "https://gaia.blockstack.org/hub/17hEAjUUWp5wN9SEGYqxpdtjHKzWVkmHEo/0/profile.json"
+//
+// is held as its $ORIGIN, $TTL and resource records. The JSON form is the one
+// of blockstack's zone-file library and blockstack-core
+package zonefile
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// defaultTTL is the TTL of records in a zone file without $TTL
+const defaultTTL = 3600
+
+// Zonefile is a parsed Blockstack zone file
+type Zonefile struct {
+	// Origin is the $ORIGIN without the trailing dot, as Blockstack writes it
+	Origin string
+	// TTL is the $TTL, 0 if the zone file has none
+	TTL uint32
+	// Records are the resource records in the order of the zone file. Names are fully qualified
+	Records []dns.RR
+}
+
+// ParseError is why a zone file could not be parsed. Line and Column are 1 based
+type ParseError struct {
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Token  string `json:"token,omitempty"`
+	Err    string `json:"error"`
+}
+
+// Error satisfies the error interface
+func (err *ParseError) Error() string {
+	if err.Token == "" {
+		return fmt.Sprintf("line %d, column %d: %s", err.Line, err.Column, err.Err)
+	}
+	return fmt.Sprintf("line %d, column %d: %s %q", err.Line, err.Column, err.Err, err.Token)
+}
+
+// JSON allows for easy Marshal
+func (err *ParseError) JSON() string {
+	byt, e := json.Marshal(err)
+	if e != nil {
+		log.Fatal(e)
+	}
+	return string(byt)
+}
+
+// PrettyJSON allows for easy Marshal
+func (err *ParseError) PrettyJSON() string {
+	byt, e := json.MarshalIndent(err, "", "    ")
+	if e != nil {
+		log.Fatal(e)
+	}
+	return string(byt)
+}
+
+// dnsParseError matches the message of a *dns.ParseError
+var dnsParseError = regexp.MustCompile(`dns: (.*): ("(?:[^"\\]|\\.)*") at line: (\d+):(\d+)$`)
+
+// newParseError returns the *ParseError for an error from the dns parser
+func newParseError(err error) *ParseError {
+	m := dnsParseError.FindStringSubmatch(err.Error())
+	if m == nil {
+		return &ParseError{Err: err.Error()}
+	}
+	pe := &ParseError{Err: m[1]}
+	pe.Token, _ = strconv.Unquote(m[2])
+	pe.Line, _ = strconv.Atoi(m[3])
+	pe.Column, _ = strconv.Atoi(m[4])
+	return pe
+}
+
+// Parse parses a zone file. Blockstack zone files have an $ORIGIN without the
+// trailing dot, so names are parsed relative to the root. The error is a *ParseError
+func Parse(text string) (*Zonefile, error) {
+	if trimmed := strings.TrimSpace(text); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		return nil, &ParseError{Line: 1, Column: 1, Err: "JSON, not a zone file"}
+	}
+	zf := &Zonefile{}
+	zp := dns.NewZoneParser(strings.NewReader(text), ".", "")
+	zp.SetDefaultTTL(defaultTTL)
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		zf.Records = append(zf.Records, rr)
+	}
+	if err := zp.Err(); err != nil {
+		return nil, newParseError(err)
+	}
+	// The parser applies the directives but does not return them
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "$ORIGIN":
+			if zf.Origin == "" {
+				zf.Origin = strings.TrimSuffix(fields[1], ".")
+			}
+		case "$TTL":
+			if zf.TTL == 0 {
+				ttl, _ := strconv.ParseUint(fields[1], 10, 32)
+				zf.TTL = uint32(ttl)
+			}
+		}
+	}
+	return zf, nil
+}
+
+// String returns zf as canonical zone text: the directives, then one record
+// per line with names relative to $ORIGIN and the TTL only where it is not $TTL
+func (zf *Zonefile) String() string {
+	var b strings.Builder
+	if zf.Origin != "" {
+		fmt.Fprintf(&b, "$ORIGIN %s\n", zf.Origin)
+	}
+	if zf.TTL != 0 {
+		fmt.Fprintf(&b, "$TTL %d\n", zf.TTL)
+	}
+	for _, rr := range zf.Records {
+		h := rr.Header()
+		b.WriteString(zf.relative(h.Name))
+		if h.Ttl != zf.ttl() {
+			fmt.Fprintf(&b, " %d", h.Ttl)
+		}
+		fmt.Fprintf(&b, " IN %s %s\n", dns.TypeToString[h.Rrtype], strings.TrimPrefix(rr.String(), h.String()))
+	}
+	return b.String()
+}
+
+// URIs returns the URI records of zf in the order of the zone file
+func (zf *Zonefile) URIs() []*dns.URI {
+	var uris []*dns.URI
+	for _, rr := range zf.Records {
+		if uri, ok := rr.(*dns.URI); ok {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
+
+// ttl returns the TTL records get when they do not set one
+func (zf *Zonefile) ttl() uint32 {
+	if zf.TTL != 0 {
+		return zf.TTL
+	}
+	return defaultTTL
+}
+
+// relative returns name relative to $ORIGIN, @ for the origin itself
+func (zf *Zonefile) relative(name string) string {
+	origin := dns.Fqdn(zf.Origin)
+	switch {
+	case strings.EqualFold(name, origin):
+		return "@"
+	case origin != "." && dns.IsSubDomain(origin, name):
+		return strings.TrimSuffix(name, "."+origin)
+	}
+	return name
+}
+
+// absolute returns the fully qualified name for a name relative to $ORIGIN
+func (zf *Zonefile) absolute(name string) string {
+	origin := dns.Fqdn(zf.Origin)
+	switch {
+	case name == "@" || name == "":
+		return origin
+	case dns.IsFqdn(name):
+		return name
+	case origin == ".":
+		return dns.Fqdn(name)
+	}
+	return name + "." + origin
+}
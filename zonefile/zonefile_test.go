@@ -0,0 +1,150 @@
+package zonefile
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+const testZonefile = `$ORIGIN muneeb.id
+$TTL 3600
+@ IN NS ns1.example.com.
+@ IN A 192.0.2.1
+@ 600 IN AAAA 2001:db8::1
+www IN CNAME muneeb.id.
+@ IN MX 10 mail.example.com.
+@ IN TXT "hello world"
+created_equal IN TXT "owner=1AYddAnfHbw6bPNvnsQFFrEuUdhMhf2XG9" "seqn=0" "parts=1" "zf0=JE9SSUdJTiBjcmVhdGVkX2VxdWFs"
+_sip._tcp IN SRV 10 5 5060 sip.example.com.
+_http._tcp IN URI 10 1 "https://gaia.blockstack.org/hub/17hEAjUUWp5wN9SEGYqxpdtjHKzWVkmHEo/0/profile.json"
+`
+
+// TestParse tests that every record and directive is read from a zone file
+func TestParse(t *testing.T) {
+	zf, err := Parse(testZonefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zf.Origin != "muneeb.id" || zf.TTL != 3600 {
+		t.Errorf("expected $ORIGIN muneeb.id and $TTL 3600, got %q %d", zf.Origin, zf.TTL)
+	}
+	if len(zf.Records) != 9 {
+		t.Fatalf("expected 9 records, got %d", len(zf.Records))
+	}
+	if h := zf.Records[2].Header(); h.Name != "muneeb.id." || h.Ttl != 600 {
+		t.Errorf("expected muneeb.id. with TTL 600, got %s %d", h.Name, h.Ttl)
+	}
+	uris := zf.URIs()
+	if len(uris) != 1 || uris[0].Header().Name != "_http._tcp.muneeb.id." {
+		t.Errorf("unexpected URI records %v", uris)
+	}
+}
+
+// TestString tests that a zone file is written in canonical form and parses back the same
+func TestString(t *testing.T) {
+	zf, err := Parse(testZonefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `$ORIGIN muneeb.id
+$TTL 3600
+@ IN NS ns1.example.com.
+@ IN A 192.0.2.1
+@ 600 IN AAAA 2001:db8::1
+www IN CNAME muneeb.id.
+@ IN MX 10 mail.example.com.
+@ IN TXT "hello world"
+created_equal IN TXT "owner=1AYddAnfHbw6bPNvnsQFFrEuUdhMhf2XG9" "seqn=0" "parts=1" "zf0=JE9SSUdJTiBjcmVhdGVkX2VxdWFs"
+_sip._tcp IN SRV 10 5 5060 sip.example.com.
+_http._tcp IN URI 10 1 "https://gaia.blockstack.org/hub/17hEAjUUWp5wN9SEGYqxpdtjHKzWVkmHEo/0/profile.json"
+`
+	if out := zf.String(); out != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+	again, err := Parse(zf.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(zf, again) {
+		t.Errorf("expected the written zone file to parse to the same records")
+	}
+}
+
+// TestJSON tests the JSON of a zone file against the shape of the zone-file library and back
+func TestJSON(t *testing.T) {
+	zf, err := Parse(testZonefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"$origin":"muneeb.id","$ttl":3600,` +
+		`"ns":[{"name":"@","host":"ns1.example.com."}],` +
+		`"a":[{"name":"@","ip":"192.0.2.1"}],` +
+		`"aaaa":[{"name":"@","ttl":600,"ip":"2001:db8::1"}],` +
+		`"cname":[{"name":"www","alias":"muneeb.id."}],` +
+		`"mx":[{"name":"@","preference":10,"host":"mail.example.com."}],` +
+		`"txt":[{"name":"@","txt":"hello world"},{"name":"created_equal","txt":["owner=1AYddAnfHbw6bPNvnsQFFrEuUdhMhf2XG9","seqn=0","parts=1","zf0=JE9SSUdJTiBjcmVhdGVkX2VxdWFs"]}],` +
+		`"srv":[{"name":"_sip._tcp","target":"sip.example.com.","priority":10,"weight":5,"port":5060}],` +
+		`"uri":[{"name":"_http._tcp","priority":10,"weight":1,"target":"https://gaia.blockstack.org/hub/17hEAjUUWp5wN9SEGYqxpdtjHKzWVkmHEo/0/profile.json"}]}`
+	if out := string(zf.JSON()); out != expected {
+		t.Errorf("expected %s, got %s", expected, out)
+	}
+
+	var back Zonefile
+	if err := json.Unmarshal([]byte(expected), &back); err != nil {
+		t.Fatal(err)
+	}
+	if out := string(back.JSON()); out != expected {
+		t.Errorf("expected the JSON to round trip, got %s", out)
+	}
+	if len(back.Records) != len(zf.Records) {
+		t.Errorf("expected %d records from the JSON, got %d", len(zf.Records), len(back.Records))
+	}
+	if _, err := Parse(back.String()); err != nil {
+		t.Errorf("expected the zone file from the JSON to parse: %v", err)
+	}
+}
+
+// TestParseErrors tests that parse errors point at where the zone file is wrong
+func TestParseErrors(t *testing.T) {
+	var tests = []struct {
+		zonefile string
+		line     int
+		token    string
+	}{
+		{"$ORIGIN muneeb.id\n$TTL 3600\n@ IN A 300.0.0.1\n", 3, "300.0.0.1"},
+		{"$ORIGIN muneeb.id\n_http._tcp IN URI 10 one \"https://example.com\"\n", 2, "one"},
+		{"$ORIGIN muneeb.id\n@ IN BOGUS something\n", 2, "BOGUS"},
+		{`{"v": "0.2", "bio": "legacy profile"}`, 1, ""},
+	}
+	for _, tt := range tests {
+		zf, err := Parse(tt.zonefile)
+		if zf != nil || err == nil {
+			t.Errorf("expected %q not to parse", tt.zonefile)
+			continue
+		}
+		pe, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("expected a *ParseError, got %T", err)
+			continue
+		}
+		if pe.Line != tt.line || pe.Token != tt.token || pe.Err == "" {
+			t.Errorf("expected an error at line %d on %q, got %s", tt.line, tt.token, pe.JSON())
+		}
+	}
+}
+
+// TestDefaultTTL tests that records in a zone file without $TTL get the default
+func TestDefaultTTL(t *testing.T) {
+	zf, err := Parse("$ORIGIN ryan.id\n@ IN A 192.0.2.1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zf.TTL != 0 || zf.Records[0].Header().Ttl != defaultTTL {
+		t.Errorf("expected no $TTL and a record TTL of %d, got %d %d", defaultTTL, zf.TTL, zf.Records[0].Header().Ttl)
+	}
+	if zf.Records[0].Header().Rrtype != dns.TypeA || string(zf.JSON()) != `{"$origin":"ryan.id","a":[{"name":"@","ip":"192.0.2.1"}]}` {
+		t.Errorf("unexpected JSON %s", zf.JSON())
+	}
+}